@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/josexy/catgo/internal/bloat"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var bloatLimit int
+
+var bloatCommand = &cobra.Command{
+	Use:   "bloat [OPTIONS]",
+	Short: "Show what takes the most space in the binary",
+	Long: `Show what takes the most space in the binary.
+
+  This command builds the package with the symbol table kept, even if the
+  profile strips it, into a separate <binary>-bloat output next to the
+  artifact of the profile, and reads the symbol table of the binary. The size of
+  the symbols is reported per module, per package and for the largest
+  symbols, with the share of the .text and .rodata sections.
+
+  The type descriptors, itabs and strings generated by the linker are
+  grouped as "(go metadata)".`,
+	RunE: runBloat,
+}
+
+func init() {
+	bloatCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build for the target triple, e.g. linux/amd64 or linux/arm/v7")
+	bloatCommand.Flags().BoolVarP(&buildRelease, "release", "r", false, "Build artifacts in release mode, with optimizations")
+	bloatCommand.Flags().StringVar(&buildProfile, "profile", "", "Build artifacts with the specified profile, e.g. dev, release or a custom one")
+	bloatCommand.Flags().StringVarP(&buildPackage, "package", "p", "", "Package to build")
+	bloatCommand.Flags().BoolVarP(&buildCGOZero, "cgo-zero", "z", false, "Build with CGO disabled")
+	bloatCommand.Flags().IntVarP(&bloatLimit, "limit", "n", 20, "Number of rows of the package and symbol tables")
+	addTargetDirFlag(bloatCommand)
+	addFeatureFlags(bloatCommand)
+	bloatCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
+	bloatCommand.RegisterFlagCompletionFunc("target", completeTargets)
+}
+
+func runBloat(cmd *cobra.Command, args []string) error {
+	if len(expandBuildTargets(buildTarget)) > 1 {
+		return fmt.Errorf("only one target can be analyzed at a time")
+	}
+	buildKeepSymbols = true
+	units, err := executeBuild(cmd)
+	if err != nil {
+		return err
+	}
+	unit := units[0]
+
+	bin, err := bloat.Read(unit.Output)
+	if err != nil {
+		if errors.Is(err, bloat.ErrNoSymbols) {
+			return fmt.Errorf("could not analyze %s: %w, remove -s from the ldflags of the profile", unit.Output, err)
+		}
+		return err
+	}
+	modules, err := bloat.Modules(context.Background(), unit.Package, unit.Flags, unit.Env)
+	if err != nil {
+		return err
+	}
+	printBloatReport(bloat.Analyze(bin, modules), bloatLimit)
+	return nil
+}
+
+func printBloatReport(report *bloat.Report, limit int) {
+	bin := report.Binary
+	total := report.Total
+	fmt.Fprintf(util.Output, "\nFile size %s (%s), symbols %s: .text %s, .rodata %s, .data %s\n",
+		util.FormatSize(bin.Size), bin.Format, util.FormatSize(total.Size),
+		util.FormatSize(total.Text), util.FormatSize(total.Rodata), util.FormatSize(total.Data))
+
+	tw := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "\nSECTION\tSIZE\tFILE%")
+	for _, s := range bin.Sections {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, util.FormatSize(s.Size), percent(s.Size, bin.Size))
+	}
+
+	fmt.Fprintln(tw, "\nMODULE\tSIZE\tFILE%\t.TEXT\tTEXT%\t.RODATA\tRODATA%")
+	for _, g := range report.Modules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", g.Name,
+			util.FormatSize(g.Size), percent(g.Size, bin.Size),
+			util.FormatSize(g.Text), percent(g.Text, total.Text),
+			util.FormatSize(g.Rodata), percent(g.Rodata, total.Rodata))
+	}
+
+	fmt.Fprintln(tw, "\nPACKAGE\tSIZE\tFILE%\t.TEXT\t.RODATA")
+	for i, g := range report.Packages {
+		if i == limit {
+			fmt.Fprintf(tw, "... %d more\t\t\t\t\n", len(report.Packages)-limit)
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", g.Name,
+			util.FormatSize(g.Size), percent(g.Size, bin.Size),
+			util.FormatSize(g.Text), util.FormatSize(g.Rodata))
+	}
+	tw.Flush()
+
+	// the symbol names are too long to be aligned with the tables above
+	tw = tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "\nSIZE\tFILE%\tSECTION\tSYMBOL")
+	for i, sym := range bin.Symbols {
+		if i == limit {
+			fmt.Fprintf(tw, "\t\t\t... %d more\n", len(bin.Symbols)-limit)
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", util.FormatSize(sym.Size), percent(sym.Size, bin.Size), sym.Section, sym.Name)
+	}
+	tw.Flush()
+}
+
+func percent(size, total int64) string {
+	return fmt.Sprintf("%.1f%%", bloat.Share(size, total))
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,22 +9,47 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/repro"
+	"github.com/josexy/catgo/internal/stamp"
+	"github.com/josexy/catgo/internal/static"
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/timings"
 	"github.com/josexy/catgo/internal/util"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	buildRelease      bool
+	buildProfile      string
 	buildOutput       string
 	buildPackage      string
 	buildTarget       string
 	buildLocal        bool
 	buildCGOZero      bool
+	buildCGOEnabled   bool
 	buildVendor       bool
 	buildSetVariables []string
+	buildJobs         int
+	buildKeepGoing    bool
+	buildStamp        bool
+	buildTimings      bool
+	buildPGO          string
+	buildRace         bool
+	buildCover        bool
+	buildCoverPkg     []string
+	buildStatic       bool
+	buildVerifyRepro  bool
+	buildMode         string
+	buildVerbose      bool
+	// keeps the symbol table in a separate -bloat output, for catgo bloat
+	buildKeepSymbols bool
 )
 
 var buildCommand = &cobra.Command{
@@ -35,111 +61,505 @@ var buildCommand = &cobra.Command{
 
   The binary name will be the package name if not specified.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, err := runBuild(cmd, args)
+		_, err := executeBuild(cmd)
 		return err
 	},
 }
 
 func init() {
-	buildCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build for the target triple, e.g. linux/amd64")
+	buildCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build for the comma-separated target triples, e.g. linux/amd64,linux/arm/v7 or all-release")
+	buildCommand.Flags().IntVarP(&buildJobs, "jobs", "j", runtime.NumCPU(), "Number of targets to build in parallel")
+	buildCommand.Flags().BoolVar(&buildKeepGoing, "keep-going", false, "Continue building the other targets after a target failed")
 	buildCommand.Flags().BoolVarP(&buildRelease, "release", "r", false, "Build artifacts in release mode, with optimizations")
+	buildCommand.Flags().StringVar(&buildProfile, "profile", "", "Build artifacts with the specified profile, e.g. dev, release or a custom one")
 	buildCommand.Flags().StringVarP(&buildOutput, "output", "o", "", "Output binary name, default to package name")
 	buildCommand.Flags().StringVarP(&buildPackage, "package", "p", "", "Package to build")
-	buildCommand.Flags().BoolVarP(&buildLocal, "local", "l", false, "Build to current directory, default to bin directory")
+	buildCommand.Flags().BoolVarP(&buildLocal, "local", "l", false, "Build to current directory, default to the target directory")
+	addTargetDirFlag(buildCommand)
 	buildCommand.Flags().BoolVarP(&buildCGOZero, "cgo-zero", "z", false, "Build with CGO disabled")
 	buildCommand.Flags().BoolVar(&buildVendor, "vendor", false, "Build with vendor directory, if a vendor directory exists it will be used")
 	buildCommand.Flags().StringSliceVarP(&buildSetVariables, "set", "x", nil, "Set Go build flags -X")
+	buildCommand.Flags().BoolVar(&buildStamp, "stamp", false, "Fill the version variables with the git version, commit, build time and Go version")
+	buildCommand.Flags().BoolVar(&buildTimings, "timings", false, "Output a build timing report to .catgo/timings")
+	buildCommand.Flags().StringVar(&buildPGO, "pgo", "", "Profile-guided optimization: auto, off or a CPU profile file")
+	addInstrumentFlags(buildCommand)
+	buildCommand.Flags().BoolVar(&buildStatic, "static", false, "Build a static binary with the netgo and osusergo tags and cgo disabled, and verify it")
+	buildCommand.Flags().BoolVar(&buildVerifyRepro, "verify-reproducible", false, "Build twice in temporary directories with a hermetic environment and compare the outputs")
+	addBuildModeFlag(buildCommand)
+	buildCommand.Flags().BoolVarP(&buildVerbose, "verbose", "v", false, "Show the effective build environment of each target")
+	addFeatureFlags(buildCommand)
+	buildCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
+	buildCommand.RegisterFlagCompletionFunc("target", completeTargets)
 }
 
-func runBuild(_ *cobra.Command, _ []string) (string, error) {
+func executeBuild(cmd *cobra.Command) (_ []*buildUnit, err error) {
 	startTime := time.Now()
+	var profileName string
+	defer func() {
+		if err != nil {
+			util.Printer.PrintBuildFailed(profileName, util.FormatDuration(time.Since(startTime)), err)
+		}
+	}()
 
 	moduleName, err := util.CurrentModuleName()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	m, err := loadManifest()
+	if err != nil {
+		return nil, err
 	}
+	applyBuildManifest(cmd.Flags(), moduleName, m)
 
-	target := buildOutput
-	if target == "" {
+	profile, err := m.ResolveProfile(selectedProfile())
+	if err != nil {
+		return nil, err
+	}
+	profileName = profile.Name
+
+	name := buildOutput
+	if name == "" {
 		parts := strings.Split(moduleName, "/")
-		target = parts[len(parts)-1]
+		name = parts[len(parts)-1]
 	} else {
-		target = filepath.Base(target)
+		name = filepath.Base(name)
 	}
 
-	var env []string
+	layout, err := resolveOutputLayout(m, profile.Name)
+	if err != nil {
+		return nil, err
+	}
 
-	targetOS, targetArch, target := parseBuildTarget(target, buildTarget)
-	if targetOS != "" {
-		env = append(env, fmt.Sprintf("GOOS=%s", targetOS))
+	if buildPackage, err = parseToGoPackage(moduleName, buildPackage); err != nil {
+		return nil, err
+	}
+
+	if buildVerifyRepro {
+		if buildTimings {
+			return nil, errors.New("--timings cannot be used with --verify-reproducible")
+		}
+		profile.Hermetic = &buildVerifyRepro
+	}
+	var hermeticEnv []string
+	if manifest.IsSet(profile.Hermetic) {
+		if hermeticEnv, err = repro.Environ(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.Flags().Changed("static") {
+		profile.Static = &buildStatic
 	}
-	if targetArch != "" {
-		env = append(env, fmt.Sprintf("GOARCH=%s", targetArch))
+	static := manifest.IsSet(profile.Static)
+	if err = validateBuildMode(buildMode, buildCGOZero || static, static); err != nil {
+		return nil, err
 	}
 
-	if buildLocal {
-		currentDir, err := util.CurrentDir()
+	tags, err := resolveTags(m, profile.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if static {
+		tags = appendTags(tags, staticTags...)
+	}
+
+	if profile.PGO, err = resolvePGO(cmd.Flags().Changed("pgo"), profile.PGO); err != nil {
+		return nil, err
+	}
+	applyInstrumentFlags(cmd.Flags(), moduleName, profile)
+
+	var stampInfo *stamp.Info
+	if buildStamp || manifest.IsSet(profile.Stamp) || manifest.IsSet(profile.RequireClean) {
+		if stampInfo, err = collectStamp(profile); err != nil {
+			return nil, err
+		}
+	}
+	stampPkg := buildPackage
+	if m.Build.StampPackage != "" {
+		stampPkg = manifestPackage(moduleName, m.Build.StampPackage)
+	}
+	if stampInfo != nil {
+		util.Printer.PrintStamping(fmt.Sprintf("%s (%s) in %s", stampInfo.Version, stampInfo.GitCommit, stampPkg))
+	}
+
+	script, err := compileBuildScript(moduleName, m)
+	if err != nil {
+		return nil, err
+	}
+
+	var units []*buildUnit
+	for _, buildTarget := range expandBuildTargets(buildTarget) {
+		triple, err := parseBuildTarget(buildTarget)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		unit := &buildUnit{Name: name, Target: triple, Mode: buildMode}
+		env := append(slices.Clone(hermeticEnv), triple.Env()...)
+		target := layout.output(unit)
+		if buildKeepSymbols {
+			// keep the profile artifact and its fingerprint
+			_, ext := artifactAffixes(unit.Mode, unit.targetOS(), unit.targetArch())
+			target = strings.TrimSuffix(target, ext) + "-bloat" + ext
+		}
+		if err = util.Mkdir(filepath.Dir(target)); err != nil {
+			return nil, err
+		}
+
+		config, _ := targetConfig(m, triple)
+		unitProfile, unitTags := profile.WithTarget(config), appendTags(slices.Clone(tags), config.Tags...)
+		if script != nil {
+			output, err := script.run(m, triple, profile.Name)
+			if err != nil {
+				return nil, err
+			}
+			// the directives apply to the target only
+			p := *unitProfile
+			p.Ldflags = append(slices.Clone(unitProfile.Ldflags), output.Ldflags...)
+			unitProfile, unitTags = &p, appendTags(unitTags, output.Tags...)
+		}
+
+		env = append(env, unitProfile.EnvList()...)
+		if buildCGOZero || static {
+			env = append(env, "CGO_ENABLED=0")
+		} else if buildCGOEnabled || buildModeRequiresCgo(buildMode) {
+			env = append(env, "CGO_ENABLED=1")
+		}
+
+		setVariables := buildSetVariables
+		if stampInfo != nil {
+			if setVariables, err = stampSetVariables(stampInfo, buildPackage, stampPkg, unitTags, env); err != nil {
+				return nil, err
+			}
+		}
+		var flags []string
+		if buildVendor {
+			flags = append(flags, "-mod=vendor")
+		}
+		if buildMode != "" && buildMode != buildModeExe {
+			flags = append(flags, "-buildmode="+buildMode)
+		}
+		flags = append(flags, profileBuildArgs(unitProfile, unitTags, setVariables)...)
+
+		unit.Output = target
+		unit.Tags = unitTags
+		unit.Flags = flags
+		unit.Package = buildPackage
+		unit.Env = env
+		unit.Hermetic = hermeticEnv != nil
+		units = append(units, unit)
+	}
+
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return nil, err
+	}
+	if buildVerbose {
+		for _, unit := range units {
+			util.Printer.PrintEnvironment(unit.displayTarget(), unit.effectiveEnv())
 		}
-		target = filepath.Join(currentDir, target)
+	}
+	plan := &buildPlan{
+		ModuleName: moduleName,
+		Units:      units,
+		Jobs:       buildJobs,
+		KeepGoing:  buildKeepGoing,
+		StateDir:   filepath.Join(goModDir, fingerprint.StateDir),
+	}
+	var timingsDir string
+	if buildTimings {
+		// the fresh detection would skip the build to be measured
+		plan.StateDir = ""
+		if timingsDir, err = os.MkdirTemp("", "catgo-timings-"); err != nil {
+			return nil, fmt.Errorf("could not create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(timingsDir)
+		for i, unit := range units {
+			unit.DebugFlags = append(unit.DebugFlags, fmt.Sprintf("-debug-actiongraph=%s", filepath.Join(timingsDir, fmt.Sprintf("%d.json", i))))
+		}
+	}
+	if buildVerifyRepro {
+		err = verifyReproducible(context.Background(), plan)
 	} else {
-		goModDir, err := util.CurrentGoModDir()
-		if err != nil {
-			return "", err
+		err = plan.execute(context.Background())
+	}
+	if len(units) > 1 {
+		printBuildSummary(units)
+	}
+	if buildTimings {
+		if reportErr := writeTimingReport(goModDir, moduleName, profile.Name, startTime, timingsDir, units); reportErr != nil {
+			util.Printer.PrintWarning(reportErr.Error())
 		}
-		outputDir := filepath.Join(goModDir, "bin")
-		if err = util.Mkdir(outputDir); err != nil {
-			return "", err
+	}
+	if err != nil {
+		if static {
+			printCgoPackages(units[0])
+		}
+		return nil, err
+	}
+	if static {
+		if err = checkStaticBinaries(units); err != nil {
+			return nil, err
+		}
+	}
+	if err = copyWasmExec(units); err != nil {
+		return nil, err
+	}
+	if !buildKeepSymbols {
+		if err = checkSizeBudget(profile, units); err != nil {
+			return nil, err
 		}
-		target = filepath.Join(outputDir, target)
 	}
 
-	util.Printer.PrintCompiling(fmt.Sprintf("%s (%s)", moduleName, target))
+	for _, unit := range units {
+		util.Printer.PrintArtifact(util.Artifact{
+			Path:    unit.Output,
+			Target:  unit.displayTarget(),
+			Profile: profile.Name,
+			Size:    unit.Size,
+		})
+	}
+	util.Printer.PrintFinished(profile.Name, util.FormatDuration(time.Since(startTime)))
+	return units, nil
+}
 
-	bldArgs := []string{"build", "-o", target}
-	if buildVendor {
-		bldArgs = append(bldArgs, "-mod=vendor")
+func writeTimingReport(goModDir, moduleName, profile string, startTime time.Time, timingsDir string, units []*buildUnit) error {
+	report := &timings.Report{
+		Module:    moduleName,
+		Profile:   profile,
+		StartTime: startTime,
+		Duration:  time.Since(startTime),
 	}
-	if buildRelease {
-		bldArgs = append(bldArgs, "-trimpath")
+	for i, unit := range units {
+		if unit.Status != buildFinished {
+			continue
+		}
+		actions, duration, err := timings.LoadActionGraph(filepath.Join(timingsDir, fmt.Sprintf("%d.json", i)))
+		if err != nil {
+			return err
+		}
+		report.Targets = append(report.Targets, &timings.Target{
+			Name:     unit.displayTarget(),
+			Output:   unit.Output,
+			Duration: duration,
+			Units:    actions,
+		})
 	}
-	if buildRelease || len(buildSetVariables) > 0 {
-		bldArgs = append(bldArgs, "-ldflags")
+	if len(report.Targets) == 0 {
+		return nil
 	}
-	var ldflags []string
-	if buildRelease {
-		ldflags = append(ldflags, "-s", "-w")
+
+	var buf bytes.Buffer
+	if err := timings.WriteHTML(&buf, report); err != nil {
+		return err
 	}
-	if len(buildSetVariables) > 0 {
-		for _, v := range buildSetVariables {
-			ldflags = append(ldflags, fmt.Sprintf("-X '%s'", v))
-		}
+	reportDir := filepath.Join(goModDir, fingerprint.StateDir, "timings")
+	if err := util.Mkdir(reportDir); err != nil {
+		return err
 	}
-	if len(ldflags) > 0 {
-		bldArgs = append(bldArgs, strings.Join(ldflags, " "))
+	reportFile := filepath.Join(reportDir, fmt.Sprintf("catgo-timing-%s.html", startTime.Format("20060102T150405")))
+	if err := util.WriteFile(reportFile, buf.Bytes()); err != nil {
+		return err
 	}
+	if err := util.WriteFile(filepath.Join(reportDir, "catgo-timing.html"), buf.Bytes()); err != nil {
+		return err
+	}
+	util.Printer.PrintCreated(fmt.Sprintf("timing report %s", reportFile))
+	return nil
+}
 
-	if buildCGOZero {
-		env = append(env, "CGO_ENABLED=0")
+func addInstrumentFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&buildRace, "race", false, "Build with the race detector enabled")
+	cmd.Flags().BoolVar(&buildCover, "cover", false, "Build with coverage instrumentation, the binary writes the coverage data to $GOCOVERDIR")
+	cmd.Flags().StringSliceVar(&buildCoverPkg, "coverpkg", nil, "Comma-separated package patterns to instrument, implies --cover")
+}
+
+func applyInstrumentFlags(flags *pflag.FlagSet, moduleName string, profile *manifest.Profile) {
+	if flags.Changed("race") {
+		profile.Race = &buildRace
+	}
+	if flags.Changed("cover") {
+		profile.Cover = &buildCover
 	}
+	if flags.Changed("coverpkg") {
+		profile.CoverPkg = buildCoverPkg
+		enabled := true
+		profile.Cover = &enabled
+		return
+	}
+	coverPkg := make([]string, 0, len(profile.CoverPkg))
+	for _, pkg := range profile.CoverPkg {
+		coverPkg = append(coverPkg, manifestPackage(moduleName, pkg))
+	}
+	profile.CoverPkg = coverPkg
+}
 
-	if buildPackage, err = parseToGoPackage(moduleName, buildPackage); err != nil {
+// the profile file is relative to the current directory on the command line
+// and to the module root in the manifest
+func resolvePGO(flagChanged bool, profilePGO string) (string, error) {
+	pgo, baseDir := profilePGO, ""
+	if flagChanged {
+		pgo = buildPGO
+	}
+	if pgo == "" || pgo == "auto" || pgo == "off" {
+		return pgo, nil
+	}
+	var err error
+	if flagChanged {
+		baseDir, err = util.CurrentDir()
+	} else {
+		baseDir, err = util.CurrentGoModDir()
+	}
+	if err != nil {
 		return "", err
 	}
+	if !filepath.IsAbs(pgo) {
+		pgo = filepath.Join(baseDir, pgo)
+	}
+	if _, err = os.Stat(pgo); err != nil {
+		return "", fmt.Errorf("PGO profile `%s` does not exist", pgo)
+	}
+	return pgo, nil
+}
 
-	bldArgs = append(bldArgs, buildPackage)
+// the pure Go implementations of the net and os/user packages
+var staticTags = []string{"netgo", "osusergo"}
 
-	if err = util.Exec(context.Background(), "go", bldArgs, env); err != nil {
-		return "", err
+func checkStaticBinaries(units []*buildUnit) error {
+	var dynamic []*buildUnit
+	for _, unit := range units {
+		result, err := static.Check(unit.Output)
+		if errors.Is(err, static.ErrNotELF) {
+			util.Printer.PrintWarning(fmt.Sprintf("target `%s` can't be verified to be static, only ELF binaries are checked", unit.displayTarget()))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !result.Static() {
+			util.Printer.PrintError(fmt.Sprintf("binary `%s` is dynamically linked: %s", unit.Output, result))
+			dynamic = append(dynamic, unit)
+		}
+	}
+	if len(dynamic) == 0 {
+		return nil
+	}
+	printCgoPackages(dynamic[0])
+	return fmt.Errorf("%d binary(s) are not static", len(dynamic))
+}
+
+func printCgoPackages(unit *buildUnit) {
+	chains, err := static.CgoPackages(context.Background(), unit.Package, unit.Flags, unit.Env)
+	if err != nil || len(chains) == 0 {
+		return
+	}
+	for _, chain := range chains {
+		if len(chain) == 1 {
+			util.Printer.PrintWarning(fmt.Sprintf("package `%s` uses cgo", chain[0]))
+			continue
+		}
+		util.Printer.PrintWarning(fmt.Sprintf("package `%s` uses cgo, imported by %s", chain[len(chain)-1], strings.Join(chain, " -> ")))
+	}
+}
+
+func checkSizeBudget(profile *manifest.Profile, units []*buildUnit) error {
+	if profile.MaxSize <= 0 {
+		return nil
+	}
+	var exceeded int
+	for _, unit := range units {
+		if unit.Size > int64(profile.MaxSize) {
+			exceeded++
+			util.Printer.PrintError(fmt.Sprintf("binary `%s` is %s, exceeding the size budget of %s by %s",
+				unit.Output, util.FormatSize(unit.Size), util.FormatSize(int64(profile.MaxSize)),
+				util.FormatSize(unit.Size-int64(profile.MaxSize))))
+		}
+	}
+	if exceeded > 0 {
+		return fmt.Errorf("%d binary(s) exceed the max-size of profile `%s`, run `catgo bloat --profile %s` to see why",
+			exceeded, profile.Name, profile.Name)
+	}
+	return nil
+}
+
+// nil is returned if the profile only requires a clean working tree
+func collectStamp(profile *manifest.Profile) (*stamp.Info, error) {
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return nil, err
+	}
+	info, err := stamp.Collect(context.Background(), goModDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.IsSet(profile.RequireClean) && info.Dirty {
+		return nil, fmt.Errorf("profile `%s` requires a clean working tree, commit or stash the changes first", profile.Name)
+	}
+	if !buildStamp && !manifest.IsSet(profile.Stamp) {
+		return nil, nil
 	}
+	return info, nil
+}
 
-	profile := "dev"
+func stampSetVariables(info *stamp.Info, pkg, stampPkg string, tags, env []string) ([]string, error) {
+	vars, err := stamp.FindVariables(context.Background(), pkg, stampPkg, tags, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(vars) == 0 {
+		util.Printer.PrintWarning(fmt.Sprintf("no version variables found in package `%s`", stampPkg))
+	}
+	return mergeSetVariables(info.SetVariables(vars), buildSetVariables), nil
+}
+
+func selectedProfile() string {
+	if buildProfile != "" {
+		return buildProfile
+	}
 	if buildRelease {
-		profile = "release"
+		return manifest.ProfileRelease
 	}
-	util.Printer.PrintFinished(profile, util.FormatDuration(time.Since(startTime)))
-	return target, nil
+	return manifest.ProfileDev
+}
+
+func profileBuildArgs(profile *manifest.Profile, tags, setVariables []string) []string {
+	var args []string
+	if manifest.IsSet(profile.Trimpath) {
+		args = append(args, "-trimpath")
+	}
+	if manifest.IsSet(profile.Race) {
+		args = append(args, "-race")
+	}
+	if manifest.IsSet(profile.Cover) {
+		args = append(args, "-cover")
+		if len(profile.CoverPkg) > 0 {
+			args = append(args, "-coverpkg", strings.Join(profile.CoverPkg, ","))
+		}
+	}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+	if profile.PGO != "" {
+		args = append(args, "-pgo="+profile.PGO)
+	}
+	if profile.Gcflags != "" {
+		args = append(args, "-gcflags", profile.Gcflags)
+	}
+	if profile.Asmflags != "" {
+		args = append(args, "-asmflags", profile.Asmflags)
+	}
+	var ldflags []string
+	if manifest.IsSet(profile.Strip) && !buildKeepSymbols {
+		ldflags = append(ldflags, "-s", "-w")
+	}
+	ldflags = append(ldflags, profile.Ldflags...)
+	for _, v := range setVariables {
+		ldflags = append(ldflags, fmt.Sprintf("-X '%s'", v))
+	}
+	if len(ldflags) > 0 {
+		args = append(args, "-ldflags", strings.Join(ldflags, " "))
+	}
+	return args
 }
 
 func parseToGoPackage(moduleName, packageName string) (string, error) {
@@ -171,32 +591,42 @@ func parseToGoPackage(moduleName, packageName string) (string, error) {
 	return packageName, nil
 }
 
-func parseBuildTarget(name, buildTarget string) (targetOS, targetArch, targetName string) {
-	const exeExt = ".exe"
-	var needWindowsExeExt bool
-	if buildTarget != "" {
-		parts := strings.Split(buildTarget, "/")
-		if len(parts) > 0 {
-			targetOS = parts[0]
-			if strings.Contains(parts[0], "windows") && !strings.HasSuffix(name, exeExt) {
-				needWindowsExeExt = true
-			}
-		}
-		if len(parts) > 1 {
-			targetArch = parts[1]
-		}
-	} else if runtime.GOOS == "windows" && !strings.HasSuffix(name, exeExt) {
-		needWindowsExeExt = true
+func parseToGoPackagePattern(moduleName, pattern string) (string, error) {
+	pkg, all := strings.CutSuffix(pattern, allPackagesSuffix)
+	pkg, err := parseToGoPackage(moduleName, pkg)
+	if err != nil {
+		return "", err
 	}
-	targetName = name
-	if targetOS != "" {
-		targetName += "-" + targetOS
+	if all {
+		pkg += allPackagesSuffix
 	}
-	if targetArch != "" {
-		targetName += "-" + targetArch
+	return pkg, nil
+}
+
+func parseBuildTarget(buildTarget string) (triple target.Triple, err error) {
+	if buildTarget == "" {
+		return
 	}
-	if needWindowsExeExt {
-		targetName += exeExt
+	if triple, err = target.ParseTriple(buildTarget); err != nil {
+		return
 	}
+	err = validateBuildTarget(triple.OS, triple.Arch)
 	return
 }
+
+func validateBuildTarget(targetOS, targetArch string) error {
+	platforms, err := target.Platforms()
+	if err != nil {
+		return err
+	}
+	if targetArch == "" {
+		// only GOOS is given, the host GOARCH is used
+		targetArch = runtime.GOARCH
+		for _, p := range platforms {
+			if p.GOOS == targetOS {
+				return nil
+			}
+		}
+	}
+	return target.Validate(platforms, targetOS, targetArch)
+}
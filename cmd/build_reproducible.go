@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/repro"
+	"github.com/josexy/catgo/internal/util"
+)
+
+const verifyRounds = 2
+
+// every build has its own build cache, the output of the first build is
+// installed if both are identical
+func verifyReproducible(ctx context.Context, plan *buildPlan) error {
+	var rounds [verifyRounds][]*buildUnit
+	for i := range rounds {
+		dir, err := os.MkdirTemp("", "catgo-reproducible-")
+		if err != nil {
+			return fmt.Errorf("could not create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		units := make([]*buildUnit, len(plan.Units))
+		for j, unit := range plan.Units {
+			u := *unit
+			// the nested layout gives every target the same base name
+			u.Output = filepath.Join(dir, "out", strconv.Itoa(j), filepath.Base(unit.Output))
+			u.Env = append(slices.Clone(unit.Env), "GOCACHE="+filepath.Join(dir, "cache"))
+			units[j] = &u
+		}
+		round := &buildPlan{
+			ModuleName: plan.ModuleName,
+			Units:      units,
+			Jobs:       plan.Jobs,
+		}
+		util.Printer.PrintVerifying(fmt.Sprintf("build %d of %d in %s", i+1, verifyRounds, dir))
+		err = round.execute(ctx)
+		for j, unit := range plan.Units {
+			unit.Status, unit.Err, unit.Elapsed = units[j].Status, units[j].Err, units[j].Elapsed
+		}
+		if err != nil {
+			return err
+		}
+		rounds[i] = units
+	}
+
+	var failed int
+	for j, unit := range plan.Units {
+		first, second := rounds[0][j], rounds[1][j]
+		sum, err := util.FileSHA256(first.Output)
+		if err != nil {
+			return err
+		}
+		other, err := util.FileSHA256(second.Output)
+		if err != nil {
+			return err
+		}
+		if sum != other {
+			unit.Status = buildFailed
+			failed++
+			sections, err := repro.DiffSections(first.Output, second.Output)
+			if err != nil {
+				util.Printer.PrintWarning(err.Error())
+			}
+			util.Printer.PrintError(fmt.Sprintf("target `%s` is not reproducible: sha256 %s != %s, differing sections: %s",
+				unit.displayTarget(), sum, other, strings.Join(sections, ", ")))
+			continue
+		}
+		if err = installFile(first.Output, unit.Output, 0755); err != nil {
+			return err
+		}
+		if header := first.header(); header != "" && util.PathExist(header) {
+			if err = installFile(header, unit.header(), 0644); err != nil {
+				return err
+			}
+		}
+		if plan.StateDir != "" {
+			fingerprint.Remove(plan.StateDir, unit.Output)
+		}
+		unit.Size = first.Size
+		util.Printer.PrintVerifying(fmt.Sprintf("%s is reproducible (sha256 %s)", unit.displayTarget(), sum))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d target(s) are not reproducible", failed, len(plan.Units))
+	}
+	return nil
+}
+
+func installFile(src, dst string, perm os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("could not read file: %w", err)
+	}
+	if err = os.WriteFile(dst, data, perm); err != nil {
+		return fmt.Errorf("could not write file %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/util"
+)
+
+func TestVerifyReproducibleMultiTarget(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/demo\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	plan := &buildPlan{ModuleName: "example.com/demo", Jobs: 2}
+	for _, triple := range []target.Triple{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}} {
+		output := filepath.Join(dir, "target", triple.Suffix(), "demo")
+		if err := util.Mkdir(filepath.Dir(output)); err != nil {
+			t.Fatal(err)
+		}
+		plan.Units = append(plan.Units, &buildUnit{
+			Name:    "demo",
+			Target:  triple,
+			Output:  output,
+			Flags:   []string{"-trimpath"},
+			Package: ".",
+			Env:     append(triple.Env(), "CGO_ENABLED=0"),
+		})
+	}
+	if err := verifyReproducible(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	amd64, err := util.FileSHA256(plan.Units[0].Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arm64, err := util.FileSHA256(plan.Units[1].Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amd64 == arm64 {
+		t.Errorf("the outputs of linux/amd64 and linux/arm64 are identical")
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/josexy/catgo/internal/buildscript"
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/util"
+)
+
+type compiledBuildScript struct {
+	*buildscript.Script
+	hash string
+}
+
+func compileBuildScript(moduleName string, m *manifest.Manifest) (*compiledBuildScript, error) {
+	if m.Package.Build == "" {
+		return nil, nil
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return nil, err
+	}
+	script := &buildscript.Script{
+		Package:   manifestPackage(moduleName, m.Package.Build),
+		ModuleDir: goModDir,
+		StateDir:  filepath.Join(goModDir, fingerprint.StateDir, "build-script"),
+	}
+	hash, fresh, err := script.Compile(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		util.Printer.PrintCompiling(fmt.Sprintf("%s (build script)", script.Package))
+	}
+	return &compiledBuildScript{Script: script, hash: hash}, nil
+}
+
+func (s *compiledBuildScript) run(m *manifest.Manifest, triple target.Triple, profile string) (*buildscript.Output, error) {
+	activated, _, err := m.ResolveFeatures(features, allFeatures, noDefaultFeatures)
+	if err != nil {
+		return nil, err
+	}
+	env := &buildscript.Env{
+		Target:   triple.String(),
+		OS:       triple.OS,
+		Arch:     triple.Arch,
+		Profile:  profile,
+		Features: activated,
+	}
+	if env.OS == "" {
+		env.OS, env.Arch = runtime.GOOS, runtime.GOARCH
+		env.Target = env.OS + "/" + env.Arch
+	}
+
+	output, fresh, err := s.Run(context.Background(), s.hash, env)
+	if err != nil {
+		return nil, err
+	}
+	if fresh {
+		return output, nil
+	}
+	binary, _ := filepath.Rel(s.ModuleDir, s.Binary())
+	util.Printer.PrintRunning(util.FormatCommandArgs("CATGO_TARGET="+env.Target, []string{binary}))
+	for _, warning := range output.Warnings {
+		util.Printer.PrintWarning(fmt.Sprintf("build script: %s", warning))
+	}
+	return output, nil
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/util"
+)
+
+var releaseTargets = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"linux/amd64",
+	"linux/arm64",
+	"linux/arm",
+	"windows/amd64",
+	"windows/arm64",
+}
+
+var targetAliases = map[string][]string{
+	"all-release": releaseTargets,
+}
+
+type buildStatus int
+
+const (
+	buildPending buildStatus = iota
+	buildFinished
+	buildFresh
+	buildFailed
+	buildSkipped
+)
+
+func (status buildStatus) String() string {
+	switch status {
+	case buildFinished:
+		return "FINISHED"
+	case buildFresh:
+		return "FRESH"
+	case buildFailed:
+		return "FAILED"
+	case buildSkipped:
+		return "SKIPPED"
+	}
+	return "PENDING"
+}
+
+type buildUnit struct {
+	// without the target suffix
+	Name   string
+	Target target.Triple
+	Output string
+	Tags   []string
+	// without -o
+	Flags   []string
+	Package string
+	Env     []string
+	// runs the build with Env only, without the catgo environment
+	Hermetic bool
+	// empty for the default -buildmode
+	Mode string
+	// the go build flags which don't affect the output
+	DebugFlags []string
+
+	Status  buildStatus
+	Size    int64
+	Elapsed time.Duration
+	Err     error
+}
+
+// the host target is represented by an empty string
+func expandBuildTargets(spec string) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, target := range strings.Split(spec, ",") {
+		target = strings.TrimSpace(target)
+		expanded, ok := targetAliases[target]
+		if !ok {
+			expanded = []string{target}
+		}
+		for _, target := range expanded {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	if len(targets) > 1 && seen[""] {
+		targets = removeString(targets, "")
+	}
+	return targets
+}
+
+func removeString(list []string, s string) []string {
+	var result []string
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+type buildPlan struct {
+	ModuleName string
+	Units      []*buildUnit
+	Jobs       int
+	KeepGoing  bool
+	// fresh detection is disabled if empty
+	StateDir string
+}
+
+// the output is buffered when more than one unit is built to avoid interleaving
+func (plan *buildPlan) execute(ctx context.Context) error {
+	jobs := plan.Jobs
+	if jobs <= 0 {
+		jobs = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, jobs)
+	)
+	parallel := len(plan.Units) > 1
+	for _, unit := range plan.Units {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			unit.Status = buildSkipped
+			continue
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			hash, fresh := plan.checkFresh(ctx, unit)
+			if fresh {
+				mu.Lock()
+				defer mu.Unlock()
+				util.Printer.PrintFresh(unit.describe(plan.ModuleName))
+				unit.Status = buildFresh
+				if info, err := os.Stat(unit.Output); err == nil {
+					unit.Size = info.Size()
+				}
+				return
+			}
+
+			mu.Lock()
+			util.Printer.PrintCompiling(unit.describe(plan.ModuleName))
+			mu.Unlock()
+
+			var output bytes.Buffer
+			buildOutput := util.Printer.BuildOutput(unit.displayTarget())
+			execIO := util.ExecIO{Stdout: buildOutput, Stderr: buildOutput}
+			if parallel {
+				execIO = util.ExecIO{Stdout: &output, Stderr: &output}
+			}
+			execIO.ClearEnv = unit.Hermetic
+			startTime := time.Now()
+			err := util.Exec(ctx, "go", unit.Args(), unit.Env, execIO)
+			unit.Elapsed = time.Since(startTime)
+
+			mu.Lock()
+			defer mu.Unlock()
+			output.WriteTo(buildOutput)
+			buildOutput.Close()
+			if err != nil {
+				if plan.StateDir != "" {
+					fingerprint.Remove(plan.StateDir, unit.Output)
+				}
+				if ctx.Err() != nil && !plan.KeepGoing {
+					// cancelled by another failed target
+					unit.Status = buildSkipped
+					return
+				}
+				unit.Status = buildFailed
+				unit.Err = err
+				if parallel {
+					util.Printer.PrintError(fmt.Sprintf("target `%s`: %v", unit.displayTarget(), err))
+				}
+				if !plan.KeepGoing {
+					cancel()
+				}
+				return
+			}
+			unit.Status = buildFinished
+			if info, err := os.Stat(unit.Output); err == nil {
+				unit.Size = info.Size()
+			}
+			if hash != "" {
+				if err = fingerprint.Save(plan.StateDir, unit.Output, hash); err != nil {
+					util.Printer.PrintWarning(fmt.Sprintf("could not save fingerprint: %v", err))
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	var failed []*buildUnit
+	for _, unit := range plan.Units {
+		if unit.Status == buildFailed {
+			failed = append(failed, unit)
+		}
+	}
+	switch {
+	case len(failed) == 1 && len(plan.Units) == 1:
+		return failed[0].Err
+	case len(failed) > 0:
+		return fmt.Errorf("could not build %d of %d target(s)", len(failed), len(plan.Units))
+	}
+	return nil
+}
+
+// an empty hash is returned if the detection is disabled
+func (plan *buildPlan) checkFresh(ctx context.Context, unit *buildUnit) (string, bool) {
+	if plan.StateDir == "" {
+		return "", false
+	}
+	hash, err := fingerprint.Compute(ctx, unit.Package, unit.Flags, unit.Env, unit.Hermetic)
+	if err != nil {
+		// let go build report the error
+		return "", false
+	}
+	return hash, fingerprint.IsFresh(plan.StateDir, unit.Output, hash)
+}
+
+func (u *buildUnit) Args() []string {
+	args := []string{"build", "-o", u.Output}
+	args = append(args, u.Flags...)
+	args = append(args, u.DebugFlags...)
+	return append(args, u.Package)
+}
+
+func (u *buildUnit) describe(moduleName string) string {
+	if len(u.Tags) == 0 {
+		return fmt.Sprintf("%s (%s)", moduleName, u.Output)
+	}
+	return fmt.Sprintf("%s (%s) [tags: %s]", moduleName, u.Output, strings.Join(u.Tags, ","))
+}
+
+func (u *buildUnit) displayTarget() string {
+	if u.Target.OS == "" {
+		return "host"
+	}
+	return u.Target.String()
+}
+
+func (u *buildUnit) effectiveEnv() []string {
+	return fingerprint.RelevantEnv(u.Env, u.Hermetic)
+}
+
+func (u *buildUnit) fileName(withSuffix bool) string {
+	prefix, ext := artifactAffixes(u.Mode, u.targetOS(), u.targetArch())
+	name := u.Name
+	if ext != "" {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if !strings.HasPrefix(name, prefix) {
+		name = prefix + name
+	}
+	if suffix := u.Target.Suffix(); withSuffix && suffix != "" {
+		name += "-" + suffix
+	}
+	return name + ext
+}
+
+// the header is only written when the package has //export functions
+func (u *buildUnit) header() string {
+	if !buildModeHasHeader(u.Mode) {
+		return ""
+	}
+	return strings.TrimSuffix(u.Output, filepath.Ext(u.Output)) + ".h"
+}
+
+func (u *buildUnit) targetOS() string {
+	if u.Target.OS == "" {
+		return runtime.GOOS
+	}
+	return u.Target.OS
+}
+
+func (u *buildUnit) targetArch() string {
+	if u.Target.Arch == "" {
+		return runtime.GOARCH
+	}
+	return u.Target.Arch
+}
+
+func printBuildSummary(units []*buildUnit) {
+	results := make([]util.BuildTargetResult, 0, len(units))
+	for _, unit := range units {
+		result := util.BuildTargetResult{
+			Target: unit.displayTarget(),
+			Status: unit.Status.String(),
+			Output: unit.Output,
+		}
+		if unit.Status == buildFinished || unit.Status == buildFresh {
+			result.Size = unit.Size
+		}
+		if unit.Status == buildFinished || unit.Status == buildFailed {
+			result.Elapsed = unit.Elapsed
+		}
+		results = append(results, result)
+	}
+	util.Printer.PrintBuildSummary(results)
+}
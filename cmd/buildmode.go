@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	buildModeExe      = "exe"
+	buildModePIE      = "pie"
+	buildModeCShared  = "c-shared"
+	buildModeCArchive = "c-archive"
+	buildModePlugin   = "plugin"
+)
+
+var buildModes = []string{buildModeExe, buildModePIE, buildModeCShared, buildModeCArchive, buildModePlugin}
+
+func addBuildModeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&buildMode, "buildmode", "", "Build mode: "+strings.Join(buildModes, ", "))
+	cmd.RegisterFlagCompletionFunc("buildmode", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return buildModes, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func validateBuildMode(mode string, cgoDisabled, static bool) error {
+	if mode == "" {
+		return nil
+	}
+	if !slices.Contains(buildModes, mode) {
+		return fmt.Errorf("unknown build mode `%s`, expected one of %s", mode, strings.Join(buildModes, ", "))
+	}
+	if cgoDisabled && buildModeRequiresCgo(mode) {
+		return fmt.Errorf("build mode `%s` requires cgo, it can't be used with --cgo-zero or --static", mode)
+	}
+	// a pie binary is dynamically loaded, it would always fail the static check
+	if static && mode == buildModePIE {
+		return fmt.Errorf("build mode `%s` can't be used with --static", mode)
+	}
+	return nil
+}
+
+func buildModeRequiresCgo(mode string) bool {
+	return mode == buildModeCShared || mode == buildModeCArchive || mode == buildModePlugin
+}
+
+func buildModeHasHeader(mode string) bool {
+	return mode == buildModeCShared || mode == buildModeCArchive
+}
+
+func artifactAffixes(mode, goos, goarch string) (prefix, ext string) {
+	if goarch == "wasm" {
+		return "", ".wasm"
+	}
+	switch mode {
+	case buildModeCShared:
+		switch goos {
+		case "windows":
+			return "", ".dll"
+		case "darwin", "ios":
+			return "lib", ".dylib"
+		}
+		return "lib", ".so"
+	case buildModeCArchive:
+		return "lib", ".a"
+	case buildModePlugin:
+		return "", ".so"
+	}
+	if goos == "windows" {
+		return "", ".exe"
+	}
+	return "", ""
+}
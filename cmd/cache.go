@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/josexy/catgo/internal/cache"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var cacheInfoAges = []string{"7d", "30d", "90d"}
+
+var (
+	cacheTop       int
+	cacheOlderThan string
+	cacheDryRun    bool
+	cacheUnused    bool
+	cacheRoots     []string
+	cacheYes       bool
+)
+
+var cacheCommand = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the go build and module caches",
+	Long: `Inspect and clean the go build and module caches.
+
+  The caches are located with "go env GOCACHE GOMODCACHE".`,
+}
+
+var cacheInfoCommand = &cobra.Command{
+	Use:   "info [OPTIONS]",
+	Short: "Show the location and the size of the caches",
+	Long: `Show the location and the size of the caches.
+
+  The build cache entries are named by hash and can't be attributed to
+  modules, their size is reported by the time since their last use instead.
+  The largest module versions of the module cache are listed.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheInfo,
+}
+
+var cacheGCCommand = &cobra.Command{
+	Use:   "gc [OPTIONS]",
+	Short: "Remove the build cache entries which were not used recently",
+	Long: `Remove the build cache entries which were not used recently.
+
+  The go command updates the modification time of a build cache entry when
+  it uses it, the entries not used for the --older-than duration are removed.
+  A removed entry is rebuilt by the next build which needs it.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheGC,
+}
+
+var cacheModulesCommand = &cobra.Command{
+	Use:   "modules [OPTIONS]",
+	Short: "List the module versions of the module cache",
+	Long: `List the module versions of the module cache.
+
+  With --unused, only the module versions which are not required by any
+  go.mod file under the --root directories are listed, and they can be
+  deleted from the module cache after a confirmation. Their .mod and .info
+  files are kept to resolve the module graphs which still reference them.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheModules,
+}
+
+func init() {
+	cacheInfoCommand.Flags().IntVarP(&cacheTop, "top", "n", 10, "Number of largest module versions to show")
+	cacheGCCommand.Flags().StringVar(&cacheOlderThan, "older-than", "30d", "Remove the entries not used for the duration, e.g. 30d, 2w or 12h")
+	cacheGCCommand.Flags().BoolVar(&cacheDryRun, "dry-run", false, "Report the entries to remove without removing them")
+	cacheModulesCommand.Flags().BoolVar(&cacheUnused, "unused", false, "Only list the module versions not required by any go.mod under the roots")
+	cacheModulesCommand.Flags().StringSliceVar(&cacheRoots, "root", []string{"."}, "Comma-separated project roots searched for go.mod files")
+	cacheModulesCommand.Flags().BoolVarP(&cacheYes, "yes", "y", false, "Delete the unused module versions without confirmation")
+	cacheCommand.AddCommand(cacheInfoCommand, cacheGCCommand, cacheModulesCommand)
+}
+
+func runCacheInfo(cmd *cobra.Command, args []string) error {
+	dirs, err := cache.Locate(context.Background())
+	if err != nil {
+		return err
+	}
+	buildUsage, err := cache.DirUsage(dirs.Build)
+	if err != nil {
+		return err
+	}
+	modUsage, err := cache.DirUsage(dirs.Modules)
+	if err != nil {
+		return err
+	}
+	modules, err := cache.ScanModules(dirs.Modules)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "CACHE\tLOCATION\tSIZE\tFILES")
+	fmt.Fprintf(tw, "build\t%s\t%s\t%d\n", dirs.Build, util.FormatSize(buildUsage.Size), buildUsage.Files)
+	fmt.Fprintf(tw, "modules\t%s\t%s\t%d\n", dirs.Modules, util.FormatSize(modUsage.Size), modUsage.Files)
+
+	fmt.Fprintln(tw, "\nNOT USED FOR\tBUILD ENTRIES\tSIZE\t")
+	for _, age := range cacheInfoAges {
+		d, _ := cache.ParseAge(age)
+		usage, err := cache.ScanBuild(dirs.Build, time.Now().Add(-d))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", age, usage.Unused.Files, util.FormatSize(usage.Unused.Size))
+	}
+
+	fmt.Fprintf(tw, "\nMODULE\tVERSION\tSIZE\t\n")
+	for i, m := range modules {
+		if i == cacheTop {
+			fmt.Fprintf(tw, "... %d more\t\t\t\n", len(modules)-cacheTop)
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", m.Path, m.Version, util.FormatSize(m.Size))
+	}
+	return tw.Flush()
+}
+
+func runCacheGC(cmd *cobra.Command, args []string) error {
+	age, err := cache.ParseAge(cacheOlderThan)
+	if err != nil {
+		return err
+	}
+	dirs, err := cache.Locate(context.Background())
+	if err != nil {
+		return err
+	}
+	removed, err := cache.TrimBuild(dirs.Build, time.Now().Add(-age), cacheDryRun)
+	if err != nil {
+		return err
+	}
+	if cacheDryRun {
+		util.Printer.PrintSuccess(fmt.Sprintf("%d build cache file(s) not used for %s, %s would be freed", removed.Files, cacheOlderThan, util.FormatSize(removed.Size)))
+		return nil
+	}
+	util.Printer.PrintSuccess(fmt.Sprintf("%d build cache file(s) not used for %s removed, %s freed", removed.Files, cacheOlderThan, util.FormatSize(removed.Size)))
+	return nil
+}
+
+func runCacheModules(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	dirs, err := cache.Locate(ctx)
+	if err != nil {
+		return err
+	}
+	modules, err := cache.ScanModules(dirs.Modules)
+	if err != nil {
+		return err
+	}
+	if cacheUnused {
+		roots := make([]string, 0, len(cacheRoots))
+		for _, root := range cacheRoots {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return fmt.Errorf("could not resolve root %s: %w", root, err)
+			}
+			roots = append(roots, abs)
+		}
+		required, files, err := cache.Required(ctx, roots, dirs.Modules)
+		if err != nil {
+			return err
+		}
+		if files == 0 {
+			return fmt.Errorf("no go.mod file found under %s", strings.Join(cacheRoots, ", "))
+		}
+		modules = cache.Unused(modules, required)
+		util.Printer.PrintSuccess(fmt.Sprintf("%d go.mod file(s) read, %d module version(s) are not required", files, len(modules)))
+	}
+
+	var total int64
+	tw := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tVERSION\tSIZE")
+	for _, m := range modules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", m.Path, m.Version, util.FormatSize(m.Size))
+		total += m.Size
+	}
+	fmt.Fprintf(tw, "total\t\t%s\n", util.FormatSize(total))
+	tw.Flush()
+
+	if !cacheUnused || len(modules) == 0 {
+		return nil
+	}
+	if !cacheYes {
+		ok, err := confirm(fmt.Sprintf("Delete %d module version(s), %s?", len(modules), util.FormatSize(total)))
+		if err != nil || !ok {
+			return err
+		}
+	}
+	for _, m := range modules {
+		util.Printer.PrintRemoving(m.String())
+		if err = cache.RemoveModule(dirs.Modules, m); err != nil {
+			return err
+		}
+	}
+	util.Printer.PrintSuccess(fmt.Sprintf("%d module version(s) removed, %s freed", len(modules), util.FormatSize(total)))
+	return nil
+}
+
+func confirm(question string) (bool, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		util.Printer.PrintWarning("stdin is not a terminal, run with --yes to delete")
+		return false, nil
+	}
+	fmt.Fprintf(util.Output, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err == io.EOF {
+		fmt.Fprintln(util.Output)
+		util.Printer.PrintWarning("no answer, run with --yes to delete")
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not read answer: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
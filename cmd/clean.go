@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,8 +13,19 @@ import (
 var cleanCommand = &cobra.Command{
 	Use:   "clean [OPTIONS]",
 	Short: "Remove all generated binaries for the local package",
-	Long:  `Remove all generated binaries for the local package.`,
-	RunE:  runClean,
+	Long: `Remove all generated binaries for the local package.
+
+  This command removes the target directory, or only the directory of a profile
+  with --profile, and the binaries of the package in the bin directory.`,
+	RunE: runClean,
+}
+
+var cleanProfile string
+
+func init() {
+	cleanCommand.Flags().StringVar(&cleanProfile, "profile", "", "Only remove the artifacts of the specified profile")
+	addTargetDirFlag(cleanCommand)
+	cleanCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
 
 func runClean(cmd *cobra.Command, args []string) error {
@@ -25,10 +37,45 @@ func runClean(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	parts := strings.Split(moduleName, "/")
-	target := filepath.Join(goModDir, "bin", parts[len(parts)-1])
-
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	name := m.Package.Output
+	if name == "" {
+		parts := strings.Split(moduleName, "/")
+		name = parts[len(parts)-1]
+	} else {
+		name = filepath.Base(name)
+	}
 	var removed []string
+	targetDir, err := resolveTargetDir(goModDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(targetDir, goModDir); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("refusing to remove target directory %s which contains the module", targetDir)
+	}
+	if cleanProfile != "" {
+		// the profile may no longer be in the manifest, but it is a single directory
+		if cleanProfile == "." || cleanProfile == ".." || strings.ContainsAny(cleanProfile, `/\`) {
+			return fmt.Errorf("invalid profile name `%s`", cleanProfile)
+		}
+		profileDir := filepath.Join(targetDir, cleanProfile)
+		if rel, err := filepath.Rel(targetDir, profileDir); err != nil || rel != cleanProfile {
+			return fmt.Errorf("refusing to remove %s outside of the target directory %s", profileDir, targetDir)
+		}
+		targetDir = profileDir
+	}
+	if util.PathExist(targetDir) {
+		if err = os.RemoveAll(targetDir); err != nil {
+			return fmt.Errorf("could not remove %s: %w", targetDir, err)
+		}
+		removed = append(removed, targetDir)
+	}
+
+	// the binaries of the bin layout
+	target := filepath.Join(goModDir, layoutBin, name)
 	patterns := []string{target, target + "-*"}
 	for _, pattern := range patterns {
 		matches, _ := filepath.Glob(pattern)
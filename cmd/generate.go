@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/generate"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generatePackage string
+	generateJobs    int
+	generateCheck   bool
+	generateForce   bool
+)
+
+var generateCommand = &cobra.Command{
+	Use:   "generate [OPTIONS]",
+	Short: "Run the //go:generate directives whose inputs changed",
+	Long: `Run the //go:generate directives whose inputs changed.
+
+  The directives of different packages run in parallel, the directives of a
+  package run in the source order like "go generate" does.
+
+  A directive reruns when its command, the file containing it or the files
+  matching the globs of a "//catgo:inputs" comment changed, or when the files
+  matching the globs of a "//catgo:outputs" comment were modified. The globs
+  are relative to the package directory and the comments must directly
+  precede the directive:
+
+      //catgo:inputs schema/*.sql
+      //catgo:outputs models_gen.go
+      //go:generate go run ./internal/gen -o models_gen.go
+
+  With --check, the generated files which changed are reported and the command
+  fails, to detect the out-of-date generated files in CI.`,
+	RunE: runGenerate,
+}
+
+func init() {
+	generateCommand.Flags().StringVarP(&generatePackage, "package", "p", "./...", "The packages to generate, default to all packages")
+	generateCommand.Flags().IntVarP(&generateJobs, "jobs", "j", runtime.NumCPU(), "Number of packages to generate in parallel")
+	generateCommand.Flags().BoolVar(&generateCheck, "check", false, "Fail if running the generators changed any file")
+	generateCommand.Flags().BoolVarP(&generateForce, "force", "f", false, "Run all the directives, even the up-to-date ones")
+	addFeatureFlags(generateCommand)
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	moduleName, err := util.CurrentModuleName()
+	if err != nil {
+		return err
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	tags, err := resolveTags(m, nil)
+	if err != nil {
+		return err
+	}
+	pattern, err := parseToGoPackagePattern(moduleName, generatePackage)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	directives, err := generate.Find(ctx, pattern, tags)
+	if err != nil {
+		return err
+	}
+	if len(directives) == 0 {
+		util.Printer.PrintWarning(fmt.Sprintf("no //go:generate directives found in `%s`", pattern))
+		return nil
+	}
+
+	stateFile := filepath.Join(goModDir, fingerprint.StateDir, "generate.json")
+	state, err := generate.LoadState(stateFile)
+	if err != nil {
+		return err
+	}
+	var before map[string]string
+	if generateCheck {
+		if before, err = generate.SnapshotTree(goModDir); err != nil {
+			return err
+		}
+	}
+
+	run := &generateRun{moduleDir: goModDir, tags: tags, state: state}
+	run.execute(ctx, directives)
+	if err = state.Save(stateFile); err != nil {
+		return err
+	}
+
+	if run.failed > 0 {
+		return fmt.Errorf("%d of %d directive(s) failed", run.failed, len(directives))
+	}
+	if generateCheck {
+		after, err := generate.SnapshotTree(goModDir)
+		if err != nil {
+			return err
+		}
+		if changed := generate.ChangedFiles(before, after); len(changed) > 0 {
+			for _, name := range changed {
+				rel, _ := filepath.Rel(goModDir, name)
+				util.Printer.PrintError(fmt.Sprintf("generated file `%s` is out of date", rel))
+			}
+			return fmt.Errorf("%d generated file(s) are out of date, run `catgo generate` and commit the changes", len(changed))
+		}
+	}
+	util.Printer.PrintSuccess(fmt.Sprintf("%d directive(s) run, %d up to date", run.ran, run.fresh))
+	return nil
+}
+
+type generateRun struct {
+	moduleDir string
+	tags      []string
+	state     *generate.State
+
+	mu                 sync.Mutex
+	ran, fresh, failed int
+}
+
+func (r *generateRun) execute(ctx context.Context, directives []*generate.Directive) {
+	// group by package, keeping the order of the packages and of the directives
+	var packages [][]*generate.Directive
+	index := make(map[string]int)
+	for _, d := range directives {
+		i, ok := index[d.Package]
+		if !ok {
+			i = len(packages)
+			index[d.Package] = i
+			packages = append(packages, nil)
+		}
+		packages[i] = append(packages[i], d)
+	}
+
+	jobs := max(generateJobs, 1)
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for _, pkg := range packages {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			for _, d := range pkg {
+				if !r.generate(ctx, d) {
+					// the next directives may depend on this one
+					break
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func (r *generateRun) generate(ctx context.Context, d *generate.Directive) bool {
+	key := d.Key(r.moduleDir)
+	inputHash, err := d.InputHash()
+	if err != nil {
+		r.fail(d, err, nil)
+		return false
+	}
+	outputs, err := d.OutputHashes()
+	if err != nil {
+		r.fail(d, err, nil)
+		return false
+	}
+	r.mu.Lock()
+	record := r.state.Records[key]
+	if !generateForce && record.UpToDate(inputHash, outputs) {
+		r.fresh++
+		r.mu.Unlock()
+		return true
+	}
+	rel, _ := filepath.Rel(r.moduleDir, d.File)
+	util.Printer.PrintGenerating(fmt.Sprintf("%s (%s:%d)", d.Command(), rel, d.Line))
+	r.mu.Unlock()
+
+	var output bytes.Buffer
+	if err = d.Run(ctx, r.tags, util.ExecIO{Stdout: &output, Stderr: &output}); err != nil {
+		r.mu.Lock()
+		delete(r.state.Records, key)
+		r.mu.Unlock()
+		r.fail(d, err, &output)
+		return false
+	}
+	// the generator may have rewritten its own inputs
+	if inputHash, err = d.InputHash(); err == nil {
+		outputs, err = d.OutputHashes()
+	}
+	if err != nil {
+		r.fail(d, err, &output)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	output.WriteTo(util.Stdout)
+	r.state.Records[key] = &generate.Record{InputHash: inputHash, Outputs: outputs}
+	r.ran++
+	return true
+}
+
+func (r *generateRun) fail(d *generate.Directive, err error, output *bytes.Buffer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if output != nil {
+		output.WriteTo(util.Stdout)
+	}
+	util.Printer.PrintError(fmt.Sprintf("%s:%d: %v", d.File, d.Line, err))
+	r.failed++
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/oci"
+	"github.com/josexy/catgo/internal/stamp"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageName string
+	imageBase string
+	imageDest string
+)
+
+var imageCommand = &cobra.Command{
+	Use:   "image [OPTIONS]",
+	Short: "Build an OCI container image of the package",
+	Long: `Build an OCI container image of the package.
+
+  This command builds the package with the release profile and writes an OCI
+  image layout with the binary, for linux with the host architecture by
+  default. The image is generated without a container daemon nor network
+  access, it can be loaded or pushed with docker load, podman load or skopeo.
+
+  The binary is added on top of the --base root filesystem tarball if given,
+  otherwise it's built static. The entrypoint, env, labels and the other
+  settings of the image are read from the [image] section of catgo.toml.`,
+	RunE: runImage,
+}
+
+func init() {
+	imageCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build the image for the comma-separated target triples, default to [image] targets or linux/<host arch>")
+	imageCommand.Flags().StringVar(&buildProfile, "profile", "", "Build artifacts with the specified profile, default to release")
+	imageCommand.Flags().StringVarP(&buildPackage, "package", "p", "", "Package to build")
+	imageCommand.Flags().IntVarP(&buildJobs, "jobs", "j", runtime.NumCPU(), "Number of targets to build in parallel")
+	imageCommand.Flags().BoolVar(&buildStamp, "stamp", false, "Fill the version variables with the git version, commit, build time and Go version")
+	imageCommand.Flags().BoolVar(&buildStatic, "static", false, "Build a static binary with the netgo and osusergo tags and cgo disabled, default without --base")
+	imageCommand.Flags().StringVar(&buildPGO, "pgo", "", "Profile-guided optimization: auto, off or a CPU profile file")
+	imageCommand.Flags().StringVar(&imageName, "name", "", "Image reference, e.g. example.com/app:1.0, default to [image] name or <binary>:latest")
+	imageCommand.Flags().StringVar(&imageBase, "base", "", "Tarball of the base root filesystem, optionally gzip-compressed")
+	imageCommand.Flags().StringVar(&imageDest, "dest", "", "Output image layout directory, or tarball if it ends with .tar, default to <target-dir>/image")
+	addTargetDirFlag(imageCommand)
+	addFeatureFlags(imageCommand)
+	imageCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
+	imageCommand.RegisterFlagCompletionFunc("target", completeTargets)
+}
+
+func runImage(cmd *cobra.Command, args []string) error {
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("target") {
+		targets := "linux/" + runtime.GOARCH
+		if len(m.Image.Targets) > 0 {
+			targets = strings.Join(m.Image.Targets, ",")
+		}
+		cmd.Flags().Set("target", targets)
+	}
+	specs := expandBuildTargets(buildTarget)
+	for _, spec := range specs {
+		triple, err := parseBuildTarget(spec)
+		if err != nil {
+			return err
+		}
+		if triple.OS == "windows" {
+			return fmt.Errorf("target `%s` is not supported, windows images need a windows base layer", spec)
+		}
+	}
+	if !cmd.Flags().Changed("profile") {
+		cmd.Flags().Set("profile", manifest.ProfileRelease)
+	}
+
+	name := imageName
+	if name == "" {
+		name = m.Image.Name
+	}
+	if name != "" {
+		if name, err = oci.ParseReference(name); err != nil {
+			return err
+		}
+	}
+	ports, err := exposedPorts(m.Image.Ports)
+	if err != nil {
+		return err
+	}
+
+	layout, err := oci.NewLayout()
+	if err != nil {
+		return err
+	}
+	defer layout.Close()
+
+	var base *oci.Layer
+	if imageBase == "" && m.Image.Base != "" {
+		imageBase = filepath.Join(goModDir, m.Image.Base)
+	}
+	if imageBase != "" {
+		// the base root filesystem is built for a single platform
+		if len(specs) > 1 {
+			return fmt.Errorf("base layer %s can't be used for %d targets, build an image per target", imageBase, len(specs))
+		}
+		if base, err = layout.TarLayer(imageBase); err != nil {
+			return err
+		}
+	} else if !cmd.Flags().Changed("static") {
+		// there is no C library without a base layer
+		cmd.Flags().Set("static", "true")
+	}
+
+	dest := imageDest
+	if dest == "" {
+		targetDir, err := resolveTargetDir(goModDir)
+		if err != nil {
+			return err
+		}
+		dest = filepath.Join(targetDir, "image")
+	}
+
+	units, err := executeBuild(cmd)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if name, err = oci.ParseReference(strings.ToLower(units[0].Name)); err != nil {
+			return err
+		}
+	}
+
+	info, err := stamp.Collect(context.Background(), goModDir)
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		"org.opencontainers.image.title":   units[0].Name,
+		"org.opencontainers.image.created": info.BuildTime.Format(time.RFC3339),
+	}
+	if info.Version != "unknown" {
+		labels["org.opencontainers.image.version"] = info.Version
+	}
+	if info.GitCommit != "unknown" {
+		labels["org.opencontainers.image.revision"] = info.GitCommit
+	}
+	maps.Copy(labels, m.Image.Labels)
+
+	var images []*oci.Image
+	for _, unit := range units {
+		path := m.Image.Path
+		if path == "" {
+			path = "/" + unit.fileName(false)
+		}
+		entrypoint := m.Image.Entrypoint
+		if len(entrypoint) == 0 {
+			entrypoint = []string{path}
+		}
+		layer, err := layout.FileLayer([]oci.File{{Path: path, Src: unit.Output, Mode: 0755}}, "catgo image "+path)
+		if err != nil {
+			return err
+		}
+		image := &oci.Image{
+			Platform: imagePlatform(unit),
+			Config: oci.Config{
+				User:         m.Image.User,
+				ExposedPorts: ports,
+				Env:          imageEnv(m.Image.Env),
+				Entrypoint:   entrypoint,
+				Cmd:          m.Image.Cmd,
+				WorkingDir:   m.Image.Workdir,
+				Labels:       labels,
+			},
+			Layers:  []*oci.Layer{layer},
+			Created: info.BuildTime,
+		}
+		if base != nil {
+			image.Layers = []*oci.Layer{base, layer}
+		}
+		util.Printer.PrintPackaging(fmt.Sprintf("%s (%s)", name, image.Platform))
+		images = append(images, image)
+	}
+
+	digest, err := layout.Add(name, images)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(dest, ".tar") {
+		if err = util.Mkdir(filepath.Dir(dest)); err != nil {
+			return err
+		}
+		err = layout.WriteTar(dest)
+	} else {
+		err = layout.WriteDir(dest)
+	}
+	if err != nil {
+		return err
+	}
+	util.Printer.PrintSuccess(fmt.Sprintf("image %s written to %s (%s)", name, dest, digest))
+	return nil
+}
+
+// the variant is the major version of the sub-architecture, e.g. v7 for linux/arm/7
+func imagePlatform(unit *buildUnit) oci.Platform {
+	platform := oci.Platform{OS: unit.targetOS(), Architecture: unit.targetArch()}
+	variant, _, _ := strings.Cut(unit.Target.Variant, ",")
+	switch platform.Architecture {
+	case "arm":
+		platform.Variant = "v7"
+		if variant != "" {
+			platform.Variant = "v" + strings.TrimPrefix(variant, "v")
+		}
+	case "arm64":
+		if variant != "" {
+			platform.Variant, _, _ = strings.Cut(variant, ".")
+		}
+	case "amd64":
+		platform.Variant = variant
+	}
+	return platform
+}
+
+func imageEnv(env map[string]string) []string {
+	var list []string
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		list = append(list, key+"="+env[key])
+	}
+	return list
+}
+
+var portPattern = regexp.MustCompile(`^[0-9]{1,5}(/(tcp|udp|sctp))?$`)
+
+func exposedPorts(ports []string) (map[string]struct{}, error) {
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	exposed := make(map[string]struct{}, len(ports))
+	for _, port := range ports {
+		if !portPattern.MatchString(port) {
+			return nil, fmt.Errorf("invalid port `%s` in [image], expected e.g. 8080 or 53/udp", port)
+		}
+		if !strings.Contains(port, "/") {
+			port += "/tcp"
+		}
+		exposed[port] = struct{}{}
+	}
+	return exposed, nil
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/josexy/catgo/internal/manifest"
 	"github.com/josexy/catgo/internal/util"
 	"github.com/josexy/catgo/internal/util/template"
 	"github.com/spf13/cobra"
@@ -70,6 +72,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if !util.PathExist(manifest.FileName) {
+		parts := strings.Split(moduleName, "/")
+		content := fmt.Sprintf(template.ManifestFile, parts[len(parts)-1])
+		if err := util.WriteFile(manifest.FileName, []byte(content)); err != nil {
+			return err
+		}
+	}
+
 	if !util.PathExist(".gitignore") {
 		if err := util.WriteFile(".gitignore", []byte(template.GitIgnoreFile)); err != nil {
 			return err
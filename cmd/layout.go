@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+const targetDirEnv = "CATGO_TARGET_DIR"
+
+const (
+	layoutTarget = "target"
+	layoutBin    = "bin"
+)
+
+var buildTargetDir string
+
+func addTargetDirFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&buildTargetDir, "target-dir", "", "Directory of the build artifacts, default to $"+targetDirEnv+" or target")
+}
+
+func resolveTargetDir(goModDir string) (string, error) {
+	dir := buildTargetDir
+	if dir == "" {
+		dir = os.Getenv(targetDirEnv)
+	}
+	if dir == "" {
+		return filepath.Join(goModDir, layoutTarget), nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve target directory: %w", err)
+	}
+	return abs, nil
+}
+
+type outputLayout struct {
+	Dir string
+	// <Dir>/<os>-<arch>/ without the target suffix
+	Nested bool
+}
+
+// <target-dir>/<profile>/<os>-<arch>/ by default, bin/ in the compatibility
+// layout and the current directory with --local
+func resolveOutputLayout(m *manifest.Manifest, profile string) (*outputLayout, error) {
+	if buildLocal {
+		dir, err := util.CurrentDir()
+		if err != nil {
+			return nil, err
+		}
+		return &outputLayout{Dir: dir}, nil
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return nil, err
+	}
+	switch m.Build.Layout {
+	case "", layoutTarget:
+		dir, err := resolveTargetDir(goModDir)
+		if err != nil {
+			return nil, err
+		}
+		return &outputLayout{Dir: filepath.Join(dir, profile), Nested: true}, nil
+	case layoutBin:
+		return &outputLayout{Dir: filepath.Join(goModDir, layoutBin)}, nil
+	}
+	return nil, fmt.Errorf("unknown layout `%s` in [build], expected `%s` or `%s`", m.Build.Layout, layoutTarget, layoutBin)
+}
+
+func (l *outputLayout) output(unit *buildUnit) string {
+	if !l.Nested {
+		return filepath.Join(l.Dir, unit.fileName(true))
+	}
+	triple := unit.Target
+	if triple.OS == "" {
+		triple.OS = runtime.GOOS
+	}
+	if triple.Arch == "" {
+		triple.Arch = runtime.GOARCH
+	}
+	return filepath.Join(l.Dir, triple.Suffix(), unit.fileName(false))
+}
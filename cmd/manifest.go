@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var currentManifest *manifest.Manifest
+
+var (
+	features          []string
+	allFeatures       bool
+	noDefaultFeatures bool
+)
+
+func addFeatureFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&features, "features", "F", nil, "Comma-separated list of features to activate")
+	cmd.Flags().BoolVar(&allFeatures, "all-features", false, "Activate all available features")
+	cmd.Flags().BoolVar(&noDefaultFeatures, "no-default-features", false, "Do not activate the default features")
+	cmd.RegisterFlagCompletionFunc("features", completeFeatures)
+}
+
+func loadManifest() (*manifest.Manifest, error) {
+	if currentManifest != nil {
+		return currentManifest, nil
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return nil, err
+	}
+	m, err := manifest.Load(goModDir)
+	if err != nil {
+		return nil, err
+	}
+	currentManifest = m
+	return m, nil
+}
+
+func completeProfiles(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	m, err := loadManifest()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return m.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeFeatures(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	m, err := loadManifest()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return m.FeatureNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func resolveTags(m *manifest.Manifest, profileTags []string) ([]string, error) {
+	_, featureTags, err := m.ResolveFeatures(features, allFeatures, noDefaultFeatures)
+	if err != nil {
+		return nil, err
+	}
+	return appendTags(slices.Clone(profileTags), featureTags...), nil
+}
+
+func appendTags(tags []string, extra ...string) []string {
+	for _, tag := range extra {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// the manifest paths are relative to the module root, not the current directory
+func manifestPackage(moduleName, pkg string) string {
+	if pkg == "" || strings.HasPrefix(pkg, moduleName) {
+		return pkg
+	}
+	suffix := ""
+	if strings.HasSuffix(pkg, allPackagesSuffix) {
+		pkg = strings.TrimSuffix(pkg, allPackagesSuffix)
+		suffix = allPackagesSuffix
+	}
+	return path.Join(moduleName, pkg) + suffix
+}
+
+func applyBuildManifest(flags *pflag.FlagSet, moduleName string, m *manifest.Manifest) {
+	if !flags.Changed("package") && m.Package.Main != "" {
+		buildPackage = manifestPackage(moduleName, m.Package.Main)
+	}
+	if !flags.Changed("output") && m.Package.Output != "" {
+		buildOutput = m.Package.Output
+	}
+	if !flags.Changed("release") && m.Build.Release {
+		buildRelease = true
+	}
+	if !flags.Changed("release") && !flags.Changed("profile") && m.Build.Profile != "" {
+		buildProfile = m.Build.Profile
+	}
+	if !flags.Changed("cgo-zero") && m.Build.CGO != nil {
+		buildCGOZero = !*m.Build.CGO
+		buildCGOEnabled = *m.Build.CGO
+	}
+	if !flags.Changed("vendor") && m.Build.Vendor {
+		buildVendor = true
+	}
+	buildSetVariables = mergeSetVariables(m.Build.SetVariables(), buildSetVariables)
+}
+
+func mergeSetVariables(base, overrides []string) []string {
+	if len(base) == 0 {
+		return overrides
+	}
+	index := make(map[string]int, len(base))
+	merged := make([]string, 0, len(base)+len(overrides))
+	for _, vars := range [][]string{base, overrides} {
+		for _, v := range vars {
+			name, _, _ := strings.Cut(v, "=")
+			if i, ok := index[name]; ok {
+				merged[i] = v
+				continue
+			}
+			index[name] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
+
+func applyTestManifest(flags *pflag.FlagSet, moduleName string, m *manifest.Manifest) {
+	t := m.Test
+	if !flags.Changed("package") && t.Package != "" {
+		testPackage = manifestPackage(moduleName, t.Package)
+	}
+	if !flags.Changed("race") && t.Race {
+		testRace = true
+	}
+	if !flags.Changed("verbose") && t.Verbose {
+		testVerbose = true
+	}
+	if !flags.Changed("fail-fast") && t.FailFast {
+		testFailFast = true
+	}
+	if !flags.Changed("full-path") && t.FullPath {
+		testFullPath = true
+	}
+	if !flags.Changed("count") && t.Count > 0 {
+		testCount = t.Count
+	}
+	if !flags.Changed("timeout") && t.Timeout.Duration > 0 {
+		testTimeout = t.Timeout.Duration
+	}
+	if !flags.Changed("cpu") && len(t.Cpu) > 0 {
+		testCpus = t.Cpu
+	}
+}
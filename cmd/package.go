@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/josexy/catgo/internal/archive"
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/stamp"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checksumsFileName = "SHA256SUMS"
+	artifactsFileName = "artifacts.json"
+)
+
+var defaultDistFiles = []string{"README*", "LICENSE*", "COPYING*"}
+
+var packageDistDir string
+
+var packageCommand = &cobra.Command{
+	Use:   "package [OPTIONS]",
+	Short: "Build and package release archives for all the targets",
+	Long: `Build and package release archives for all the targets.
+
+  This command builds the package for the targets listed in the [dist] section
+  of catgo.toml (default to all-release) with the release profile, and writes
+  a .tar.gz archive for each Unix target and a .zip archive for each Windows
+  target into the dist directory.
+
+  Every archive contains the binary, the README and LICENSE files and the
+  extra files listed in [dist] include. The SHA256SUMS and artifacts.json
+  files describing the archives are written next to them.`,
+	RunE: runPackage,
+}
+
+func init() {
+	packageCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Package for the comma-separated target triples, default to [dist] targets or all-release")
+	packageCommand.Flags().StringVar(&buildProfile, "profile", "", "Build artifacts with the specified profile, default to release")
+	packageCommand.Flags().StringVarP(&buildPackage, "package", "p", "", "Package to build")
+	packageCommand.Flags().IntVarP(&buildJobs, "jobs", "j", runtime.NumCPU(), "Number of targets to build in parallel")
+	packageCommand.Flags().BoolVar(&buildKeepGoing, "keep-going", false, "Continue building the other targets after a target failed")
+	packageCommand.Flags().BoolVar(&buildStamp, "stamp", false, "Fill the version variables with the git version, commit, build time and Go version")
+	packageCommand.Flags().StringVar(&packageDistDir, "dist-dir", "", "Output directory of the archives, default to dist")
+	packageCommand.Flags().BoolVar(&buildStatic, "static", false, "Build a static binary with the netgo and osusergo tags and cgo disabled, and verify it")
+	packageCommand.Flags().StringVar(&buildPGO, "pgo", "", "Profile-guided optimization: auto, off or a CPU profile file")
+	addBuildModeFlag(packageCommand)
+	addTargetDirFlag(packageCommand)
+	addFeatureFlags(packageCommand)
+	packageCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
+	packageCommand.RegisterFlagCompletionFunc("target", completeTargets)
+}
+
+type distArtifact struct {
+	Name    string   `json:"name"`
+	Target  string   `json:"target"`
+	Kind    string   `json:"kind"`
+	Profile string   `json:"profile"`
+	Size    int64    `json:"size"`
+	SHA256  string   `json:"sha256"`
+	Files   []string `json:"files,omitempty"`
+}
+
+type distManifest struct {
+	Module    string          `json:"module"`
+	Version   string          `json:"version"`
+	GitCommit string          `json:"git_commit"`
+	Artifacts []*distArtifact `json:"artifacts"`
+}
+
+func runPackage(cmd *cobra.Command, args []string) error {
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	goModDir, err := util.CurrentGoModDir()
+	if err != nil {
+		return err
+	}
+	moduleName, err := util.CurrentModuleName()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("target") {
+		targets := "all-release"
+		if len(m.Dist.Targets) > 0 {
+			targets = strings.Join(m.Dist.Targets, ",")
+		}
+		cmd.Flags().Set("target", targets)
+	}
+	if !cmd.Flags().Changed("profile") {
+		profile := manifest.ProfileRelease
+		if m.Dist.Profile != "" {
+			profile = m.Dist.Profile
+		}
+		cmd.Flags().Set("profile", profile)
+	}
+
+	distDir := packageDistDir
+	if distDir == "" {
+		distDir = m.Dist.Dir
+		if distDir == "" {
+			distDir = "dist"
+		}
+		distDir = filepath.Join(goModDir, distDir)
+	}
+	if err = util.Mkdir(distDir); err != nil {
+		return err
+	}
+
+	extraFiles, err := distFiles(goModDir, m.Dist.Include)
+	if err != nil {
+		return err
+	}
+
+	units, err := executeBuild(cmd)
+	if err != nil {
+		return err
+	}
+
+	info, err := stamp.Collect(context.Background(), goModDir)
+	if err != nil {
+		return err
+	}
+	dist := distManifest{
+		Module:    moduleName,
+		Version:   info.Version,
+		GitCommit: info.GitCommit,
+	}
+	for _, unit := range units {
+		artifact, err := packageUnit(unit, distDir, extraFiles)
+		if err != nil {
+			return err
+		}
+		artifact.Profile = buildProfile
+		dist.Artifacts = append(dist.Artifacts, artifact)
+	}
+
+	if err = writeChecksums(filepath.Join(distDir, checksumsFileName), dist.Artifacts); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(&dist, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %w", artifactsFileName, err)
+	}
+	if err = util.WriteFile(filepath.Join(distDir, artifactsFileName), append(data, '\n')); err != nil {
+		return err
+	}
+	util.Printer.PrintSuccess(fmt.Sprintf("%d archive(s) written to %s", len(dist.Artifacts), distDir))
+	return nil
+}
+
+func distFiles(goModDir string, include []string) ([]archive.File, error) {
+	var files []archive.File
+	seen := make(map[string]bool)
+	add := func(pattern string, required bool) error {
+		matches, err := filepath.Glob(filepath.Join(goModDir, pattern))
+		if err != nil {
+			return fmt.Errorf("invalid include pattern `%s`: %w", pattern, err)
+		}
+		if len(matches) == 0 && required {
+			return fmt.Errorf("include pattern `%s` matches no files", pattern)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() || seen[match] {
+				continue
+			}
+			seen[match] = true
+			rel, _ := filepath.Rel(goModDir, match)
+			files = append(files, archive.File{Name: filepath.ToSlash(rel), Path: match})
+		}
+		return nil
+	}
+	for _, pattern := range defaultDistFiles {
+		if err := add(pattern, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range include {
+		if err := add(pattern, true); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func packageUnit(unit *buildUnit, distDir string, extraFiles []archive.File) (*distArtifact, error) {
+	_, ext := artifactAffixes(unit.Mode, unit.targetOS(), unit.targetArch())
+	binaryName := unit.fileName(false)
+	archiveName := strings.TrimSuffix(unit.fileName(true), ext)
+	isWindows := unit.targetOS() == "windows"
+
+	files := []archive.File{{Name: binaryName, Path: unit.Output}}
+	if header := unit.header(); header != "" && util.PathExist(header) {
+		files = append(files, archive.File{Name: strings.TrimSuffix(binaryName, ext) + ".h", Path: header})
+	}
+	if unit.Target.OS == "js" && unit.Target.Arch == "wasm" {
+		files = append(files, archive.File{Name: wasmExecJS, Path: filepath.Join(filepath.Dir(unit.Output), wasmExecJS)})
+	}
+	files = append(files, extraFiles...)
+	var err error
+	kind := "tar.gz"
+	if isWindows {
+		kind = "zip"
+	}
+	archiveName += "." + kind
+	archivePath := filepath.Join(distDir, archiveName)
+	util.Printer.PrintPackaging(fmt.Sprintf("%s (%s)", archiveName, unit.displayTarget()))
+	if isWindows {
+		err = archive.WriteZip(archivePath, files)
+	} else {
+		err = archive.WriteTarGz(archivePath, files)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat archive: %w", err)
+	}
+	sum, err := util.FileSHA256(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	artifact := &distArtifact{
+		Name:   archiveName,
+		Target: unit.displayTarget(),
+		Kind:   kind,
+		Size:   info.Size(),
+		SHA256: sum,
+	}
+	for _, file := range files {
+		artifact.Files = append(artifact.Files, file.Name)
+	}
+	return artifact, nil
+}
+
+func writeChecksums(name string, artifacts []*distArtifact) error {
+	sorted := append([]*distArtifact(nil), artifacts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	var sb strings.Builder
+	for _, artifact := range sorted {
+		fmt.Fprintf(&sb, "%s  %s\n", artifact.SHA256, artifact.Name)
+	}
+	return util.WriteFile(name, []byte(sb.String()))
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/josexy/catgo/internal/pgo"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// larger than the report to find the functions which cooled down
+const pgoCompareDepth = 1000
+
+var (
+	pgoPackage       string
+	pgoBench         string
+	pgoBenchPackage  string
+	pgoBenchTime     time.Duration
+	pgoCount         int
+	pgoFrom          []string
+	pgoOutput        string
+	pgoMergeExisting bool
+	pgoTop           int
+)
+
+var pgoCommand = &cobra.Command{
+	Use:   "pgo",
+	Short: "Manage the profile-guided optimization profile",
+	Long: `Manage the profile-guided optimization profile.
+
+  The go toolchain optimizes the build of a main package with the default.pgo
+  CPU profile placed in its directory, see "catgo build --pgo".`,
+}
+
+var pgoCollectCommand = &cobra.Command{
+	Use:   "collect [OPTIONS]",
+	Short: "Collect a CPU profile from benchmarks into default.pgo",
+	Long: `Collect a CPU profile from benchmarks into default.pgo.
+
+  This command runs the selected benchmarks of every package with a CPU
+  profile, or reads the existing pprof files given with --from, and merges
+  the profiles into the default.pgo file of the main package.
+
+  The hottest functions of the new profile are reported with their share of
+  the samples in the previous default.pgo.`,
+	RunE: runPGOCollect,
+}
+
+func init() {
+	pgoCollectCommand.Flags().StringVarP(&pgoPackage, "package", "p", "", "Main package to optimize")
+	pgoCollectCommand.Flags().StringVar(&pgoBench, "bench", ".", "Run only the benchmarks matching the regular expression")
+	pgoCollectCommand.Flags().StringVar(&pgoBenchPackage, "bench-package", "./...", "Packages whose benchmarks are run")
+	pgoCollectCommand.Flags().DurationVar(&pgoBenchTime, "bench-time", 0, "Run each benchmark for duration d")
+	pgoCollectCommand.Flags().IntVarP(&pgoCount, "count", "c", 1, "The number of times to run each benchmark")
+	pgoCollectCommand.Flags().StringSliceVar(&pgoFrom, "from", nil, "Merge the existing pprof files instead of running benchmarks")
+	pgoCollectCommand.Flags().StringVarP(&pgoOutput, "output", "o", "", "Output profile, default to default.pgo of the main package")
+	pgoCollectCommand.Flags().BoolVar(&pgoMergeExisting, "merge-existing", false, "Merge the existing profile with the new one instead of replacing it")
+	pgoCollectCommand.Flags().IntVarP(&pgoTop, "top", "n", 10, "Number of hot functions to report")
+	addFeatureFlags(pgoCollectCommand)
+	pgoCommand.AddCommand(pgoCollectCommand)
+}
+
+func runPGOCollect(cmd *cobra.Command, args []string) error {
+	moduleName, err := util.CurrentModuleName()
+	if err != nil {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("package") && m.Package.Main != "" {
+		pgoPackage = manifestPackage(moduleName, m.Package.Main)
+	}
+	mainPackage, err := parseToGoPackage(moduleName, pgoPackage)
+	if err != nil {
+		return err
+	}
+	if testTags, err = resolveTags(m, nil); err != nil {
+		return err
+	}
+
+	output := pgoOutput
+	if output == "" {
+		dir, err := mainPackageDir(mainPackage)
+		if err != nil {
+			return err
+		}
+		output = filepath.Join(dir, pgo.DefaultFile)
+	}
+	_, err = os.Stat(output)
+	existing := err == nil
+
+	tmpDir, err := os.MkdirTemp("", "catgo-pgo-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	profiles := pgoFrom
+	if len(profiles) == 0 {
+		if profiles, err = collectBenchmarkProfiles(moduleName, tmpDir); err != nil {
+			return err
+		}
+	}
+	if len(profiles) == 0 {
+		return fmt.Errorf("no benchmarks matching `%s` found in `%s`", pgoBench, pgoBenchPackage)
+	}
+	if pgoMergeExisting && existing {
+		profiles = append(profiles, output)
+	}
+
+	ctx := context.Background()
+	var before []pgo.HotFunction
+	if existing {
+		if before, err = pgo.HotFunctions(ctx, output, pgoCompareDepth); err != nil {
+			return err
+		}
+	}
+	if err = pgo.Merge(ctx, output, profiles); err != nil {
+		return err
+	}
+	util.Printer.PrintCreated(fmt.Sprintf("PGO profile %s from %d profile(s)", output, len(profiles)))
+
+	after, err := pgo.HotFunctions(ctx, output, pgoCompareDepth)
+	if err != nil {
+		return err
+	}
+	printHotFunctions(pgo.Compare(before, after, pgoTop), existing)
+	return nil
+}
+
+func mainPackageDir(pkg string) (string, error) {
+	output, err := util.ExecResult(context.Background(), "go", []string{"list", "-f", "{{.Name}} {{.Dir}}", pkg}, nil)
+	if err != nil {
+		return "", err
+	}
+	name, dir, _ := strings.Cut(strings.TrimSpace(string(output)), " ")
+	if name != "main" {
+		return "", fmt.Errorf("package `%s` is not a main package", pkg)
+	}
+	return dir, nil
+}
+
+// go test writes a CPU profile for a single package only
+func collectBenchmarkProfiles(moduleName, tmpDir string) ([]string, error) {
+	pattern, err := parseToGoPackagePattern(moduleName, pgoBenchPackage)
+	if err != nil {
+		return nil, err
+	}
+	listArgs := []string{"list", "-f", "{{if or .TestGoFiles .XTestGoFiles}}{{.ImportPath}}{{end}}"}
+	if len(testTags) > 0 {
+		listArgs = append(listArgs, "-tags", strings.Join(testTags, ","))
+	}
+	output, err := util.ExecResult(context.Background(), "go", append(listArgs, pattern), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	testBench = true
+	testBenchTime = pgoBenchTime
+	testCount = pgoCount
+	var profiles []string
+	for i, pkg := range strings.Fields(string(output)) {
+		profile := filepath.Join(tmpDir, fmt.Sprintf("%d.pprof", i))
+		// buildTestArgs replaces the expression once the benchmarks are selected
+		testRunExpr = "^Benchmark(" + pgoBench + ")"
+		testPackage = pkg
+		testCpuProfile = profile
+		testBinary = filepath.Join(tmpDir, fmt.Sprintf("%d.test", i))
+		if err = execGoTest(moduleName, nil); err != nil {
+			return nil, err
+		}
+		if _, err = os.Stat(profile); err == nil {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+func printHotFunctions(changes []pgo.Change, compared bool) {
+	tw := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	defer tw.Flush()
+	if !compared {
+		fmt.Fprintln(tw, "\nFLAT%\tFUNCTION")
+		for _, c := range changes {
+			fmt.Fprintf(tw, "%.2f%%\t%s\n", c.After, c.Name)
+		}
+		return
+	}
+	fmt.Fprintln(tw, "\nBEFORE\tAFTER\tCHANGE\tFUNCTION")
+	for _, c := range changes {
+		if c.Before < 0 {
+			fmt.Fprintf(tw, "-\t%.2f%%\tnew\t%s\n", c.After, c.Name)
+			continue
+		}
+		fmt.Fprintf(tw, "%.2f%%\t%.2f%%\t%+.2f\t%s\n", c.Before, c.After, c.After-c.Before, c.Name)
+	}
+}
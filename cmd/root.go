@@ -14,12 +14,19 @@ var rootCommand = &cobra.Command{
 	Version:       version.Version,
 	SilenceErrors: true,
 	SilenceUsage:  true,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := util.SetMessageFormat(messageFormat); err != nil {
+			return err
+		}
 		util.CheckGoInstalled()
+		return nil
 	},
 }
 
+var messageFormat string
+
 func init() {
+	rootCommand.PersistentFlags().StringVar(&messageFormat, "message-format", util.MessageFormatHuman, "Output format of the messages, human or json")
 	rootCommand.AddCommand(runCommand)
 	rootCommand.AddCommand(buildCommand)
 	rootCommand.AddCommand(cleanCommand)
@@ -30,6 +37,13 @@ func init() {
 	rootCommand.AddCommand(versionCommand)
 	rootCommand.AddCommand(vendorCommand)
 	rootCommand.AddCommand(testCommand)
+	rootCommand.AddCommand(targetsCommand)
+	rootCommand.AddCommand(packageCommand)
+	rootCommand.AddCommand(bloatCommand)
+	rootCommand.AddCommand(pgoCommand)
+	rootCommand.AddCommand(generateCommand)
+	rootCommand.AddCommand(cacheCommand)
+	rootCommand.AddCommand(imageCommand)
 }
 
 func Execute() {
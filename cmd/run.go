@@ -2,12 +2,21 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"time"
 
+	"github.com/josexy/catgo/internal/coverage"
+	"github.com/josexy/catgo/internal/fingerprint"
 	"github.com/josexy/catgo/internal/util"
 	"github.com/spf13/cobra"
 )
 
+var runCoverProfile string
+
 var runCommand = &cobra.Command{
 	Use:   "run [OPTIONS] [-- ARGS]",
 	Short: "Compile and run a binary of the local package",
@@ -17,30 +26,52 @@ var runCommand = &cobra.Command{
   to run. If you're passing arguments to both Catgo and the binary, the
   ones after -- go to the binary, the ones before go to Catgo.
 
-  By default, Catgo builds the binary in the bin/ directory of the current
-  package.
+  By default, Catgo builds the binary in the target directory of the current
+  package. The binaries of the targets with a runner in the [target] section
+  of catgo.toml, and of the wasm targets, are launched by the runner.
 
   This Catgo uses the current Go module to build(via "go env GOMOD"). And 
-  you can specify the package to build with the --package flag.`,
+  you can specify the package to build with the --package flag.
+
+  With --cover, the binary writes its coverage data to a directory created
+  for the run under .catgo/coverage, which is converted to a coverage profile
+  with "go tool covdata" when the binary exits.`,
 	RunE: runRun,
 }
 
 func init() {
-	runCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build for the target triple, e.g. linux/amd64")
+	runCommand.Flags().StringVarP(&buildTarget, "target", "t", "", "Build for the target triple, e.g. linux/amd64 or linux/arm/v7")
 	runCommand.Flags().BoolVarP(&buildRelease, "release", "r", false, "Build artifacts in release mode, with optimizations")
+	runCommand.Flags().StringVar(&buildProfile, "profile", "", "Build artifacts with the specified profile, e.g. dev, release or a custom one")
 	runCommand.Flags().StringVarP(&buildOutput, "output", "o", "", "Output binary name")
 	runCommand.Flags().StringVarP(&buildPackage, "package", "p", "", "Package to build")
-	runCommand.Flags().BoolVarP(&buildLocal, "local", "l", false, "Build to current directory, default to bin/ directory")
+	runCommand.Flags().BoolVarP(&buildLocal, "local", "l", false, "Build to current directory, default to the target directory")
+	addTargetDirFlag(runCommand)
 	runCommand.Flags().BoolVarP(&buildCGOZero, "cgo-zero", "z", false, "Build with CGO disabled")
 	runCommand.Flags().BoolVar(&buildVendor, "vendor", false, "Build with vendor directory, if a vendor directory exists it will be used")
 	runCommand.Flags().StringSliceVarP(&buildSetVariables, "set", "x", nil, "Set Go build flags -X")
+	runCommand.Flags().BoolVar(&buildStamp, "stamp", false, "Fill the version variables with the git version, commit, build time and Go version")
+	runCommand.Flags().StringVar(&runCoverProfile, "cover-profile", "", "Write the coverage profile of a --cover run to file")
+	addInstrumentFlags(runCommand)
+	runCommand.Flags().BoolVar(&buildStatic, "static", false, "Build a static binary with the netgo and osusergo tags and cgo disabled, and verify it")
+	runCommand.Flags().StringVar(&buildPGO, "pgo", "", "Profile-guided optimization: auto, off or a CPU profile file")
+	addFeatureFlags(runCommand)
+	runCommand.RegisterFlagCompletionFunc("profile", completeProfiles)
+	runCommand.RegisterFlagCompletionFunc("target", completeTargets)
 }
 
 func runRun(cmd *cobra.Command, args []string) error {
-	target, err := runBuild(cmd, args)
+	if len(expandBuildTargets(buildTarget)) > 1 {
+		return fmt.Errorf("only one target can be run at a time")
+	}
+	if runCoverProfile != "" && !cmd.Flags().Changed("cover") {
+		cmd.Flags().Set("cover", "true")
+	}
+	units, err := executeBuild(cmd)
 	if err != nil {
 		return err
 	}
+	target := units[0].Output
 
 	goModDir, err := util.CurrentGoModDir()
 	if err != nil {
@@ -51,9 +82,63 @@ func runRun(cmd *cobra.Command, args []string) error {
 		relTarget = target
 	}
 
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	runner, err := targetRunner(m, units[0].Target)
+	if err != nil {
+		return err
+	}
+	command, commandArgs := target, args
+	if len(runner) > 0 {
+		command, commandArgs = runner[0], append(append(slices.Clone(runner[1:]), target), args...)
+		relTarget = util.FormatCommandArgs(runner[0], append(slices.Clone(runner[1:]), relTarget))
+	}
+
+	if slices.Contains(units[0].Flags, "-cover") {
+		return runWithCoverage(goModDir, relTarget, command, args, commandArgs)
+	}
 	util.Printer.PrintRunning(util.FormatCommandArgs(relTarget, args))
-	if err = util.ExecProcess(context.Background(), target, args, nil); err != nil {
+	if err = util.ExecProcess(context.Background(), command, commandArgs, nil); err != nil {
+		if hint := runnerHint(units[0].Target); len(runner) == 0 && hint != "" {
+			return fmt.Errorf("%w, %s cannot run on the host: %s", err, units[0].displayTarget(), hint)
+		}
 		return err
 	}
 	return nil
 }
+
+func runWithCoverage(goModDir, relTarget, command string, args, commandArgs []string) error {
+	runID := time.Now().Format("20060102T150405.000")
+	coverDir := filepath.Join(goModDir, fingerprint.StateDir, "coverage", runID)
+	if err := util.Mkdir(coverDir); err != nil {
+		return err
+	}
+	profile := runCoverProfile
+	if profile == "" {
+		profile = filepath.Join(coverDir, "coverage.out")
+	}
+
+	// the interrupt is delivered to the binary too, wait for it to exit
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	util.Printer.PrintRunning(util.FormatCommandArgs(relTarget, args))
+	runErr := util.Exec(context.Background(), command, commandArgs, []string{"GOCOVERDIR=" + coverDir})
+
+	if entries, _ := os.ReadDir(coverDir); len(entries) == 0 {
+		util.Printer.PrintWarning(fmt.Sprintf("no coverage data written to %s, was the binary built with --cover?", coverDir))
+		return runErr
+	}
+	if err := coverage.Convert(context.Background(), coverDir, profile); err != nil {
+		return err
+	}
+	percent, err := coverage.Percent(profile)
+	if err != nil {
+		return err
+	}
+	util.Printer.PrintCreated(fmt.Sprintf("coverage profile %s (%.1f%% of statements)", profile, percent))
+	return runErr
+}
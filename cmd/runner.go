@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/josexy/catgo/internal/manifest"
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/util"
+)
+
+const wasmExecJS = "wasm_exec.js"
+
+// from the most specific one, e.g. linux-armv7 and linux-arm
+func targetKeys(triple target.Triple) []string {
+	if triple.OS == "" {
+		triple.OS = runtime.GOOS
+	}
+	if triple.Arch == "" {
+		triple.Arch = runtime.GOARCH
+	}
+	keys := []string{triple.Suffix()}
+	if triple.Variant != "" {
+		keys = append(keys, triple.OS+"-"+triple.Arch)
+	}
+	return keys
+}
+
+func targetConfig(m *manifest.Manifest, triple target.Triple) (manifest.TargetConfig, string) {
+	keys := targetKeys(triple)
+	for _, key := range keys {
+		if config, ok := m.Targets[key]; ok {
+			return config, key
+		}
+	}
+	return manifest.TargetConfig{}, keys[len(keys)-1]
+}
+
+func runnerEnv(key string) string {
+	return "CATGO_TARGET_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_")) + "_RUNNER"
+}
+
+// CATGO_TARGET_<OS>_<ARCH>_RUNNER wins over [target.<os>-<arch>], the wasm
+// targets default to the exec scripts of GOROOT
+func targetRunner(m *manifest.Manifest, triple target.Triple) ([]string, error) {
+	for _, key := range targetKeys(triple) {
+		if runner := strings.Fields(os.Getenv(runnerEnv(key))); len(runner) > 0 {
+			return runner, nil
+		}
+	}
+	config, key := targetConfig(m, triple)
+	if len(config.Runner) > 0 {
+		return config.Runner, nil
+	}
+	if triple.Arch != "wasm" {
+		return nil, nil
+	}
+	goroot, err := goRoot()
+	if err != nil {
+		return nil, err
+	}
+	script := filepath.Join(goroot, "lib", "wasm", fmt.Sprintf("go_%s_wasm_exec", triple.OS))
+	if !util.PathExist(script) {
+		return nil, fmt.Errorf("no runner for target `%s`, set `runner` in [target.%s] of %s", triple, key, manifest.FileName)
+	}
+	return []string{script}, nil
+}
+
+var qemuArch = map[string]string{
+	"386":     "i386",
+	"amd64":   "x86_64",
+	"arm":     "arm",
+	"arm64":   "aarch64",
+	"loong64": "loongarch64",
+	"mips":    "mips",
+	"mipsle":  "mipsel",
+	"mips64":  "mips64",
+	"ppc64":   "ppc64",
+	"ppc64le": "ppc64le",
+	"riscv64": "riscv64",
+	"s390x":   "s390x",
+}
+
+func runnerHint(triple target.Triple) string {
+	if (triple.OS == "" || triple.OS == runtime.GOOS) && (triple.Arch == "" || triple.Arch == runtime.GOARCH) {
+		return ""
+	}
+	key := targetKeys(triple)[len(targetKeys(triple))-1]
+	hint := fmt.Sprintf("set `runner` in [target.%s] of %s or %s", key, manifest.FileName, runnerEnv(key))
+	if arch, ok := qemuArch[triple.Arch]; ok && triple.OS == "linux" && runtime.GOOS == "linux" {
+		hint += fmt.Sprintf(`, e.g. runner = ["qemu-%s"]`, arch)
+	}
+	return hint
+}
+
+// go test -exec splits the runner into fields honoring the quotes
+func quoteRunner(runner []string) string {
+	quoted := make([]string, len(runner))
+	for i, arg := range runner {
+		switch {
+		case !strings.ContainsAny(arg, " \t\n'\""):
+			quoted[i] = arg
+		case !strings.Contains(arg, "'"):
+			quoted[i] = "'" + arg + "'"
+		default:
+			quoted[i] = `"` + arg + `"`
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func copyWasmExec(units []*buildUnit) error {
+	var src string
+	for _, unit := range units {
+		if unit.Target.OS != "js" || unit.Target.Arch != "wasm" {
+			continue
+		}
+		if src == "" {
+			goroot, err := goRoot()
+			if err != nil {
+				return err
+			}
+			src = filepath.Join(goroot, "lib", "wasm", wasmExecJS)
+		}
+		if err := installFile(src, filepath.Join(filepath.Dir(unit.Output), wasmExecJS), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func goRoot() (string, error) {
+	output, err := util.ExecResult(context.Background(), "go", []string{"env", "GOROOT"}, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
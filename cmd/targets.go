@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/josexy/catgo/internal/target"
+	"github.com/josexy/catgo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	targetsOS         string
+	targetsFirstClass bool
+	targetsCgo        bool
+)
+
+var targetsCommand = &cobra.Command{
+	Use:   "targets [OPTIONS]",
+	Short: "List the supported target triples",
+	Long: `List the supported target triples.
+
+  The targets are reported by "go tool dist list" of the installed Go toolchain,
+  and can be passed to the --target flag of the build and run sub-commands.`,
+	RunE: runTargets,
+}
+
+func init() {
+	targetsCommand.Flags().StringVar(&targetsOS, "os", "", "Only list the targets of the operating system")
+	targetsCommand.Flags().BoolVar(&targetsFirstClass, "first-class", false, "Only list the first-class targets")
+	targetsCommand.Flags().BoolVar(&targetsCgo, "cgo", false, "Only list the targets supporting cgo")
+}
+
+func runTargets(cmd *cobra.Command, args []string) error {
+	platforms, err := target.Platforms()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.Output, 0, 0, 3, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw, "TARGET\tCGO\tFIRST-CLASS")
+	for _, p := range platforms {
+		if targetsOS != "" && p.GOOS != targetsOS {
+			continue
+		}
+		if targetsFirstClass && !p.FirstClass {
+			continue
+		}
+		if targetsCgo && !p.CgoSupported {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p, yesNo(p.CgoSupported), yesNo(p.FirstClass))
+	}
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func completeTargets(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	platforms, err := target.Platforms()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	candidates := target.Names(platforms)
+	for alias := range targetAliases {
+		candidates = append(candidates, alias)
+	}
+	sort.Strings(candidates)
+
+	var prefix string
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(prefix+candidate, toComplete) {
+			completions = append(completions, prefix+candidate)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
@@ -32,8 +33,14 @@ var (
 	testMemProfile     string
 	testMutextProfile  string
 	testCpus           []string
+	testTags           []string
 	testTimeout        time.Duration
 	testBenchTime      time.Duration
+	testTarget         string
+	// for catgo pgo collect
+	testBinary string
+	testEnv    []string
+	testExec   string
 )
 
 var testCommand = &cobra.Command{
@@ -54,6 +61,9 @@ func init() {
 	testCommand.Flags().BoolVar(&testFullPath, "full-path", false, "Show full file names in error messages")
 	testCommand.Flags().BoolVar(&testFailFast, "fail-fast", false, "Do not start new tests after the first test failure")
 	testCommand.Flags().StringSliceVar(&testCpus, "cpu", nil, "Comma-separated list of cpu counts to run each test with")
+	testCommand.Flags().StringVar(&testTarget, "target", "", "Test for the target triple through its runner, e.g. wasip1/wasm")
+	testCommand.RegisterFlagCompletionFunc("target", completeTargets)
+	addFeatureFlags(testCommand)
 
 	testCommand.Flags().BoolVarP(&testBench, "bench", "b", false, "Run only benchmarks matching regexp via --run")
 	testCommand.Flags().BoolVar(&testBenchWithTests, "bench-test", false, "Run benchmarks with tests too")
@@ -72,23 +82,39 @@ func runTest(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	m, err := loadManifest()
+	if err != nil {
+		return err
+	}
+	applyTestManifest(cmd.Flags(), moduleName, m)
+	if testTags, err = resolveTags(m, nil); err != nil {
+		return err
+	}
+	if testTarget != "" {
+		triple, err := parseBuildTarget(testTarget)
+		if err != nil {
+			return err
+		}
+		runner, err := targetRunner(m, triple)
+		if err != nil {
+			return err
+		}
+		if hint := runnerHint(triple); len(runner) == 0 && hint != "" {
+			util.Printer.PrintWarning(fmt.Sprintf("no runner for target `%s`, the tests may not run on the host: %s", triple, hint))
+		}
+		// the [target] env and tags apply to the test binary as to the build
+		config, _ := targetConfig(m, triple)
+		testEnv, testExec = append(triple.Env(), config.EnvList()...), quoteRunner(runner)
+		testTags = appendTags(testTags, config.Tags...)
+	}
 	return execGoTest(moduleName, args)
 }
 
 func execGoTest(moduleName string, args []string) (err error) {
-	var testAllPackages bool
-	if strings.HasSuffix(testPackage, allPackagesSuffix) {
-		testPackage = strings.TrimSuffix(testPackage, allPackagesSuffix)
-		testAllPackages = true
-	}
-
-	testPackage, err = parseToGoPackage(moduleName, testPackage)
+	testPackage, err = parseToGoPackagePattern(moduleName, testPackage)
 	if err != nil {
 		return
 	}
-	if testAllPackages {
-		testPackage = testPackage + allPackagesSuffix
-	}
 
 	var mode test.TestMode
 	switch {
@@ -110,7 +136,7 @@ func execGoTest(moduleName string, args []string) (err error) {
 
 	go func() {
 		defer pw.Close()
-		errCh <- util.Exec(ctx, "go", testArgs, nil, util.ExecIO{Stdout: pw, Stderr: pw})
+		errCh <- util.Exec(ctx, "go", testArgs, testEnv, util.ExecIO{Stdout: pw, Stderr: pw})
 	}()
 
 	analyzer.Wait()
@@ -163,6 +189,9 @@ func buildTestArgs(args []string) []string {
 	if testRace {
 		testArgs = append(testArgs, "-race")
 	}
+	if len(testTags) > 0 {
+		testArgs = append(testArgs, "-tags", strings.Join(testTags, ","))
+	}
 	if testBlockProfile != "" {
 		testArgs = append(testArgs, "-blockprofile", testBlockProfile)
 	}
@@ -181,6 +210,12 @@ func buildTestArgs(args []string) []string {
 	if len(testCpus) > 0 {
 		testArgs = append(testArgs, "-cpu", strings.Join(testCpus, ","))
 	}
+	if testExec != "" {
+		testArgs = append(testArgs, "-exec", testExec)
+	}
+	if testBinary != "" {
+		testArgs = append(testArgs, "-o", testBinary)
+	}
 
 	testArgs = append(testArgs, "-json")
 	testArgs = append(testArgs, testPackage)
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+type File struct {
+	Name string
+	Path string
+}
+
+func WriteTarGz(name string, files []File) (err error) {
+	fp, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("could not create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := fp.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	gw := gzip.NewWriter(fp)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		if err = addTarFile(tw, file); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return fmt.Errorf("could not write archive %s: %w", name, err)
+	}
+	if err = gw.Close(); err != nil {
+		return fmt.Errorf("could not write archive %s: %w", name, err)
+	}
+	return nil
+}
+
+func addTarFile(tw *tar.Writer, file File) error {
+	fp, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("could not open file: %w", err)
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat file: %w", err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("could not create tar header for %s: %w", file.Path, err)
+	}
+	header.Name = path.Clean(file.Name)
+	header.Uname, header.Gname = "", ""
+	header.Uid, header.Gid = 0, 0
+	if err = tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("could not write tar header for %s: %w", file.Path, err)
+	}
+	if _, err = io.Copy(tw, fp); err != nil {
+		return fmt.Errorf("could not write %s to archive: %w", file.Path, err)
+	}
+	return nil
+}
+
+func WriteZip(name string, files []File) (err error) {
+	fp, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("could not create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := fp.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	zw := zip.NewWriter(fp)
+	for _, file := range files {
+		if err = addZipFile(zw, file); err != nil {
+			return err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("could not write archive %s: %w", name, err)
+	}
+	return nil
+}
+
+func addZipFile(zw *zip.Writer, file File) error {
+	fp, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("could not open file: %w", err)
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat file: %w", err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("could not create zip header for %s: %w", file.Path, err)
+	}
+	header.Name = path.Clean(file.Name)
+	header.Method = zip.Deflate
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("could not write zip header for %s: %w", file.Path, err)
+	}
+	if _, err = io.Copy(w, fp); err != nil {
+		return fmt.Errorf("could not write %s to archive: %w", file.Path, err)
+	}
+	return nil
+}
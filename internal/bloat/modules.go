@@ -0,0 +1,50 @@
+package bloat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Standard   bool
+	Module     *struct {
+		Path string
+	}
+}
+
+// the package main is mapped by its name as the linker does
+func Modules(ctx context.Context, pkg string, flags, env []string) (map[string]string, error) {
+	args := append([]string{"list", "-deps", "-json=ImportPath,Name,Standard,Module"}, flags...)
+	output, err := util.ExecResult(ctx, "go", append(args, pkg), env)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]string)
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not parse go list output: %w", err)
+		}
+		module := StdModule
+		if !p.Standard && p.Module != nil {
+			module = p.Module.Path
+		}
+		modules[p.ImportPath] = module
+		if p.Name == "main" {
+			modules["main"] = module
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,122 @@
+package bloat
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// the type descriptors, itabs and strings of the linker which can't be
+	// attributed to a package
+	MetadataPackage = "(go metadata)"
+	// the symbols which are not Go symbols, e.g. C code
+	UnknownPackage = "(unknown)"
+	StdModule      = "std"
+)
+
+type Group struct {
+	Name   string
+	Size   int64
+	Text   int64
+	Rodata int64
+	Data   int64
+}
+
+func (g *Group) add(sym *Symbol) {
+	g.Size += sym.Size
+	switch sym.Kind {
+	case KindText:
+		g.Text += sym.Size
+	case KindRodata:
+		g.Rodata += sym.Size
+	case KindData:
+		g.Data += sym.Size
+	}
+}
+
+type Report struct {
+	Binary   *Binary
+	Total    Group
+	Packages []*Group
+	Modules  []*Group
+}
+
+// e.g. github.com/spf13/cobra for github.com/spf13/cobra.(*Command).Execute
+func SymbolPackage(name string) string {
+	if strings.HasPrefix(name, "type:") || strings.HasPrefix(name, "go:") || strings.HasPrefix(name, "$") {
+		return MetadataPackage
+	}
+	// the type arguments of the generic instances can contain any path
+	if i := strings.IndexByte(name, '['); i > 0 {
+		name = name[:i]
+	}
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot <= 0 {
+		return UnknownPackage
+	}
+	// the linker escapes the dots of the last path element, e.g. gopkg.in/yaml%2ev3
+	return strings.ReplaceAll(name[:slash+1+dot], "%2e", ".")
+}
+
+func Analyze(bin *Binary, modules map[string]string) *Report {
+	report := &Report{Binary: bin, Total: Group{Name: "total"}}
+	packages := make(map[string]*Group)
+	mods := make(map[string]*Group)
+	group := func(groups map[string]*Group, name string) *Group {
+		g, ok := groups[name]
+		if !ok {
+			g = &Group{Name: name}
+			groups[name] = g
+		}
+		return g
+	}
+	for i := range bin.Symbols {
+		sym := &bin.Symbols[i]
+		pkg := SymbolPackage(sym.Name)
+		mod, ok := modules[pkg]
+		if !ok {
+			mod = guessModule(pkg)
+		}
+		report.Total.add(sym)
+		group(packages, pkg).add(sym)
+		group(mods, mod).add(sym)
+	}
+	report.Packages = sortGroups(packages)
+	report.Modules = sortGroups(mods)
+	return report
+}
+
+// for the packages not listed by go list, e.g. the runtime/debug symbols
+// added by the linker
+func guessModule(pkg string) string {
+	if pkg == MetadataPackage || pkg == UnknownPackage {
+		return pkg
+	}
+	first, _, _ := strings.Cut(pkg, "/")
+	if !strings.Contains(first, ".") {
+		return StdModule
+	}
+	return pkg
+}
+
+func sortGroups(groups map[string]*Group) []*Group {
+	sorted := make([]*Group, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func Share(size, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(size) * 100 / float64(total)
+}
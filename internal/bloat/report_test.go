@@ -0,0 +1,27 @@
+package bloat
+
+import "testing"
+
+func TestSymbolPackage(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"main.main", "main"},
+		{"runtime.mallocgc", "runtime"},
+		{"internal/abi.(*Type).Kind", "internal/abi"},
+		{"github.com/spf13/cobra.(*Command).Execute", "github.com/spf13/cobra"},
+		{"gopkg.in/yaml%2ev3.(*parser).parse", "gopkg.in/yaml.v3"},
+		{"slices.Sort[go.shape.[]string,go.shape.string]", "slices"},
+		{"example.com/m/list.New[example.com/m/item.T]", "example.com/m/list"},
+		{"type:*github.com/spf13/cobra.Command", MetadataPackage},
+		{"go:string.*", MetadataPackage},
+		{"$f64.3ff0000000000000", MetadataPackage},
+		{"x_cgo_init", UnknownPackage},
+	}
+	for _, tt := range tests {
+		if got := SymbolPackage(tt.name); got != tt.want {
+			t.Errorf("SymbolPackage(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package bloat
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindText   Kind = "text"
+	KindRodata Kind = "rodata"
+	KindData   Kind = "data"
+)
+
+type Symbol struct {
+	Name    string
+	Section string
+	Kind    Kind
+	Size    int64
+}
+
+type Section struct {
+	Name string
+	Kind Kind
+	Size int64
+}
+
+type Binary struct {
+	Format   string
+	Size     int64
+	Sections []Section
+	Symbols  []Symbol
+}
+
+var ErrNoSymbols = errors.New("binary has no symbol table, it is stripped")
+
+func Read(name string) (*Binary, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat binary: %w", err)
+	}
+	var bin *Binary
+	if f, err := elf.Open(name); err == nil {
+		defer f.Close()
+		bin, err = readELF(f)
+		if err != nil {
+			return nil, err
+		}
+	} else if f, err := macho.Open(name); err == nil {
+		defer f.Close()
+		bin, err = readMachO(f)
+		if err != nil {
+			return nil, err
+		}
+	} else if f, err := pe.Open(name); err == nil {
+		defer f.Close()
+		bin, err = readPE(f)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("could not read %s: unsupported executable format", name)
+	}
+	if len(bin.Symbols) == 0 {
+		return nil, ErrNoSymbols
+	}
+	bin.Size = info.Size()
+	sort.Slice(bin.Symbols, func(i, j int) bool { return bin.Symbols[i].Size > bin.Symbols[j].Size })
+	return bin, nil
+}
+
+// false is returned for the sections which take no space in the file or
+// don't hold the program
+func sectionKind(name string) (Kind, bool) {
+	name = strings.TrimLeft(name, "._")
+	switch {
+	case strings.Contains(name, "bss"), strings.HasPrefix(name, "debug"), strings.HasPrefix(name, "zdebug"):
+		return "", false
+	case strings.Contains(name, "text"):
+		return KindText, true
+	case strings.Contains(name, "rodata"), strings.Contains(name, "rdata"):
+		return KindRodata, true
+	case strings.Contains(name, "data"):
+		return KindData, true
+	case strings.Contains(name, "gopclntab"), strings.Contains(name, "typelink"),
+		strings.Contains(name, "itablink"), strings.Contains(name, "gosymtab"):
+		return KindRodata, true
+	}
+	return "", false
+}
+
+func readELF(f *elf.File) (*Binary, error) {
+	bin := &Binary{Format: "elf"}
+	for _, s := range f.Sections {
+		if kind, ok := sectionKind(s.Name); ok && s.Type != elf.SHT_NOBITS {
+			bin.Sections = append(bin.Sections, Section{Name: s.Name, Kind: kind, Size: int64(s.Size)})
+		}
+	}
+	symbols, err := f.Symbols()
+	if err != nil {
+		if errors.Is(err, elf.ErrNoSymbols) {
+			return nil, ErrNoSymbols
+		}
+		return nil, fmt.Errorf("could not read symbols: %w", err)
+	}
+	for _, sym := range symbols {
+		if sym.Size == 0 || int(sym.Section) >= len(f.Sections) || sym.Section == elf.SHN_UNDEF {
+			continue
+		}
+		section := f.Sections[sym.Section]
+		kind, ok := sectionKind(section.Name)
+		if !ok || section.Type == elf.SHT_NOBITS {
+			continue
+		}
+		bin.Symbols = append(bin.Symbols, Symbol{Name: sym.Name, Section: section.Name, Kind: kind, Size: int64(sym.Size)})
+	}
+	return bin, nil
+}
+
+// the size of a symbol without size is the distance to the next symbol of the section
+type addressed struct {
+	name    string
+	section int
+	addr    uint64
+}
+
+func sizeByAddress(symbols []addressed, sections []Section, ends []uint64) []Symbol {
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].section != symbols[j].section {
+			return symbols[i].section < symbols[j].section
+		}
+		return symbols[i].addr < symbols[j].addr
+	})
+	var result []Symbol
+	for i, sym := range symbols {
+		end := ends[sym.section]
+		if i+1 < len(symbols) && symbols[i+1].section == sym.section {
+			end = symbols[i+1].addr
+		}
+		if end <= sym.addr {
+			continue
+		}
+		section := sections[sym.section]
+		result = append(result, Symbol{Name: sym.name, Section: section.Name, Kind: section.Kind, Size: int64(end - sym.addr)})
+	}
+	return result
+}
+
+func readMachO(f *macho.File) (*Binary, error) {
+	bin := &Binary{Format: "macho"}
+	if f.Symtab == nil {
+		return nil, ErrNoSymbols
+	}
+	// index of the Mach-O section (1-based) to the index of bin.Sections
+	index := make(map[int]int)
+	var ends []uint64
+	for i, s := range f.Sections {
+		kind, ok := sectionKind(s.Name)
+		if !ok || s.Offset == 0 {
+			continue
+		}
+		index[i+1] = len(bin.Sections)
+		bin.Sections = append(bin.Sections, Section{Name: s.Name, Kind: kind, Size: int64(s.Size)})
+		ends = append(ends, s.Addr+s.Size)
+	}
+	var symbols []addressed
+	for _, sym := range f.Symtab.Syms {
+		// skip the debugging and the undefined symbols
+		if sym.Type&0xe0 != 0 || sym.Sect == 0 {
+			continue
+		}
+		if i, ok := index[int(sym.Sect)]; ok {
+			symbols = append(symbols, addressed{name: sym.Name, section: i, addr: sym.Value})
+		}
+	}
+	bin.Symbols = sizeByAddress(symbols, bin.Sections, ends)
+	return bin, nil
+}
+
+func readPE(f *pe.File) (*Binary, error) {
+	bin := &Binary{Format: "pe"}
+	index := make(map[int]int)
+	var ends []uint64
+	for i, s := range f.Sections {
+		kind, ok := sectionKind(s.Name)
+		if !ok || s.Size == 0 {
+			continue
+		}
+		index[i+1] = len(bin.Sections)
+		bin.Sections = append(bin.Sections, Section{Name: s.Name, Kind: kind, Size: int64(s.Size)})
+		end := s.Size
+		if s.VirtualSize > 0 {
+			// the raw data is padded to the file alignment
+			end = min(end, s.VirtualSize)
+		}
+		ends = append(ends, uint64(end))
+	}
+	var symbols []addressed
+	for _, sym := range f.Symbols {
+		if i, ok := index[int(sym.SectionNumber)]; ok {
+			symbols = append(symbols, addressed{name: sym.Name, section: i, addr: uint64(sym.Value)})
+		}
+	}
+	bin.Symbols = sizeByAddress(symbols, bin.Sections, ends)
+	return bin, nil
+}
@@ -0,0 +1,225 @@
+package buildscript
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/josexy/catgo/internal/fingerprint"
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Script struct {
+	Package string
+	// the working directory of the script
+	ModuleDir string
+	// StateDir holds the script binary, its output directories and the state of the runs.
+	StateDir string
+}
+
+type Env struct {
+	Target   string
+	OS       string
+	Arch     string
+	Profile  string
+	Features []string
+}
+
+func (e *Env) key() string {
+	return strings.ReplaceAll(e.Target, "/", "-")
+}
+
+func (e *Env) Environ(moduleDir, outDir string) []string {
+	env := []string{
+		"CATGO_MANIFEST_DIR=" + moduleDir,
+		"CATGO_OUT_DIR=" + outDir,
+		"CATGO_TARGET=" + e.Target,
+		"CATGO_TARGET_OS=" + e.OS,
+		"CATGO_TARGET_ARCH=" + e.Arch,
+		"CATGO_PROFILE=" + e.Profile,
+		"CATGO_FEATURES=" + strings.Join(e.Features, ","),
+	}
+	for _, feature := range e.Features {
+		name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(feature))
+		env = append(env, "CATGO_FEATURE_"+name+"=1")
+	}
+	return env
+}
+
+type state struct {
+	// covers the script inputs and the environment of the run
+	Hash   string            `json:"hash"`
+	Files  map[string]string `json:"files,omitempty"`
+	Env    map[string]string `json:"env,omitempty"`
+	Output *Output           `json:"output"`
+}
+
+func (s *Script) Binary() string {
+	name := filepath.Join(s.StateDir, "build-script")
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func (s *Script) Compile(ctx context.Context) (hash string, fresh bool, err error) {
+	// the script runs on the host, the target of the build must not leak in
+	env := []string{"GOOS=", "GOARCH=", "GOARM=", "GOAMD64=", "GOARM64=", "GO386="}
+	if hash, err = fingerprint.Compute(ctx, s.Package, nil, env, false); err != nil {
+		return "", false, err
+	}
+	binary := s.Binary()
+	if fingerprint.IsFresh(s.StateDir, binary, hash) {
+		return hash, true, nil
+	}
+	if err = util.Mkdir(s.StateDir); err != nil {
+		return "", false, err
+	}
+	if err = util.Exec(ctx, "go", []string{"build", "-o", binary, s.Package}, env, util.ExecIO{Stdout: os.Stderr}); err != nil {
+		return "", false, fmt.Errorf("could not compile build script: %w", err)
+	}
+	if err = fingerprint.Save(s.StateDir, binary, hash); err != nil {
+		return "", false, err
+	}
+	return hash, false, nil
+}
+
+func (s *Script) Run(ctx context.Context, scriptHash string, env *Env) (output *Output, fresh bool, err error) {
+	outDir := filepath.Join(s.StateDir, "out", env.key())
+	if err = util.Mkdir(outDir); err != nil {
+		return nil, false, err
+	}
+	environ := env.Environ(s.ModuleDir, outDir)
+	h := sha256.New()
+	fmt.Fprintf(h, "script %s\n", scriptHash)
+	for _, kv := range environ {
+		fmt.Fprintf(h, "env %s\n", kv)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	stateFile := filepath.Join(s.StateDir, "state", env.key()+".json")
+	if previous, err := loadState(stateFile); err == nil && s.upToDate(previous, hash) {
+		return previous.Output, true, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = util.Exec(ctx, s.Binary(), nil, environ, util.ExecIO{Stdout: &stdout, Stderr: &stderr, Stdin: bytes.NewReader(nil)})
+	if err != nil {
+		os.Remove(stateFile)
+		return nil, false, fmt.Errorf("build script failed: %w\n%s%s", err, stdout.String(), stderr.String())
+	}
+	if output, err = ParseOutput(stdout.Bytes()); err != nil {
+		return nil, false, err
+	}
+
+	st := &state{Hash: hash, Output: output}
+	if len(output.RerunIfChanged) > 0 {
+		if st.Files, err = s.snapshotFiles(output.RerunIfChanged); err != nil {
+			return nil, false, err
+		}
+	}
+	if len(output.RerunIfEnvChanged) > 0 {
+		st.Env = snapshotEnv(output.RerunIfEnvChanged)
+	}
+	if err = saveState(stateFile, st); err != nil {
+		return nil, false, err
+	}
+	return output, false, nil
+}
+
+// a script which declared no rerun-if-changed inputs is always rerun
+func (s *Script) upToDate(previous *state, hash string) bool {
+	if previous.Hash != hash || previous.Output == nil || len(previous.Output.RerunIfChanged) == 0 {
+		return false
+	}
+	files, err := s.snapshotFiles(previous.Output.RerunIfChanged)
+	if err != nil || !equalMaps(files, previous.Files) {
+		return false
+	}
+	return equalMaps(snapshotEnv(previous.Output.RerunIfEnvChanged), previous.Env)
+}
+
+func (s *Script) snapshotFiles(paths []string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.ModuleDir, path)
+		}
+		err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[name] = fmt.Sprintf("%d %d", info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if os.IsNotExist(err) {
+			// a missing input is an input too, it may be created later
+			files[path] = "missing"
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read build script input: %w", err)
+		}
+	}
+	return files, nil
+}
+
+func snapshotEnv(names []string) map[string]string {
+	env := make(map[string]string, len(names))
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			env[name] = v
+		}
+	}
+	return env
+}
+
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+func loadState(name string) (*state, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err = json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func saveState(name string, st *state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode build script state: %w", err)
+	}
+	if err = util.Mkdir(filepath.Dir(name)); err != nil {
+		return err
+	}
+	return util.WriteFile(name, data)
+}
@@ -0,0 +1,54 @@
+package buildscript
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const directivePrefix = "catgo:"
+
+type Output struct {
+	Ldflags []string `json:"ldflags,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// relative to the module root
+	RerunIfChanged    []string `json:"rerun_if_changed,omitempty"`
+	RerunIfEnvChanged []string `json:"rerun_if_env_changed,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+}
+
+func ParseOutput(stdout []byte) (*Output, error) {
+	var output Output
+	scanner := bufio.NewScanner(bytes.NewReader(stdout))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), directivePrefix)
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid build script directive `%s%s`, expected key=value", directivePrefix, line)
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "ldflags":
+			output.Ldflags = append(output.Ldflags, value)
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					output.Tags = append(output.Tags, tag)
+				}
+			}
+		case "rerun-if-changed":
+			output.RerunIfChanged = append(output.RerunIfChanged, value)
+		case "rerun-if-env-changed":
+			output.RerunIfEnvChanged = append(output.RerunIfEnvChanged, value)
+		case "warning":
+			output.Warnings = append(output.Warnings, value)
+		default:
+			return nil, fmt.Errorf("unknown build script directive `%s%s`", directivePrefix, key)
+		}
+	}
+	return &output, nil
+}
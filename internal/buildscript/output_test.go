@@ -0,0 +1,34 @@
+package buildscript
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOutput(t *testing.T) {
+	stdout := `generating assets
+catgo:tags=embed_assets, sqlite
+catgo:ldflags=-X main.Assets=42
+catgo:rerun-if-changed=assets
+catgo:rerun-if-env-changed=ASSETS_URL
+catgo:warning=assets are outdated
+`
+	want := &Output{
+		Ldflags:           []string{"-X main.Assets=42"},
+		Tags:              []string{"embed_assets", "sqlite"},
+		RerunIfChanged:    []string{"assets"},
+		RerunIfEnvChanged: []string{"ASSETS_URL"},
+		Warnings:          []string{"assets are outdated"},
+	}
+	got, err := ParseOutput([]byte(stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOutput() = %+v, want %+v", got, want)
+	}
+
+	if _, err = ParseOutput([]byte("catgo:rerun=x\n")); err == nil {
+		t.Error("ParseOutput() accepted an unknown directive")
+	}
+}
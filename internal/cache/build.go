@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// the go command updates the modification time of an entry when it uses it
+type BuildUsage struct {
+	Total  Usage
+	Unused Usage
+}
+
+func isEntryDir(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	for _, c := range name {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func walkBuild(dir string, fn func(name string, info fs.FileInfo) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read build cache: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !isEntryDir(entry.Name()) {
+			continue
+		}
+		subdir := filepath.Join(dir, entry.Name())
+		files, err := os.ReadDir(subdir)
+		if err != nil {
+			return fmt.Errorf("could not read build cache: %w", err)
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if err = fn(filepath.Join(subdir, file.Name()), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ScanBuild(dir string, cutoff time.Time) (*BuildUsage, error) {
+	var usage BuildUsage
+	err := walkBuild(dir, func(name string, info fs.FileInfo) error {
+		usage.Total.add(info.Size())
+		if info.ModTime().Before(cutoff) {
+			usage.Unused.add(info.Size())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func TrimBuild(dir string, cutoff time.Time, dryRun bool) (Usage, error) {
+	var removed Usage
+	err := walkBuild(dir, func(name string, info fs.FileInfo) error {
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(name); err != nil {
+				return fmt.Errorf("could not remove cache entry: %w", err)
+			}
+		}
+		removed.add(info.Size())
+		return nil
+	})
+	return removed, err
+}
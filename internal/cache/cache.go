@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Dirs struct {
+	Build   string `json:"GOCACHE"`
+	Modules string `json:"GOMODCACHE"`
+}
+
+func Locate(ctx context.Context) (*Dirs, error) {
+	output, err := util.ExecResult(ctx, "go", []string{"env", "-json", "GOCACHE", "GOMODCACHE"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var dirs Dirs
+	if err = json.Unmarshal(output, &dirs); err != nil {
+		return nil, fmt.Errorf("could not parse go env output: %w", err)
+	}
+	return &dirs, nil
+}
+
+type Usage struct {
+	Size  int64
+	Files int
+}
+
+func (u *Usage) add(size int64) {
+	u.Size += size
+	u.Files++
+}
+
+func DirUsage(dir string) (Usage, error) {
+	var usage Usage
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		usage.add(info.Size())
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("could not read %s: %w", dir, err)
+	}
+	return usage, nil
+}
+
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if v, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age `%s`", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age `%s`", s)
+	}
+	return d, nil
+}
+
+// the trees of the module cache are read-only
+func removeAll(name string) error {
+	filepath.WalkDir(name, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			os.Chmod(path, 0755)
+		}
+		return nil
+	})
+	return os.RemoveAll(name)
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"12h", 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1d", "10x"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) succeeded", in)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, path := range []string{"github.com/BurntSushi/toml", "github.com/spf13/cobra", "v1.0.0-RC1"} {
+		escaped := escape(path)
+		got, err := unescape(escaped)
+		if err != nil || got != path {
+			t.Errorf("unescape(%q) = %q, %v, want %q", escaped, got, err, path)
+		}
+	}
+	if got := escape("github.com/BurntSushi/toml"); got != "github.com/!burnt!sushi/toml" {
+		t.Errorf("escape() = %q", got)
+	}
+	if _, err := unescape("github.com/!Burnt"); err == nil {
+		t.Error("unescape() accepted an upper-case escaped letter")
+	}
+}
+
+func TestUnused(t *testing.T) {
+	modules := []*Module{
+		{Path: "github.com/spf13/cobra", Version: "v1.10.2"},
+		{Path: "github.com/spf13/cobra", Version: "v1.8.0"},
+		{Path: "golang.org/toolchain", Version: "v0.0.1-go1.25.0.linux-amd64"},
+	}
+	required := map[string]bool{"github.com/spf13/cobra@v1.10.2": true}
+	unused := Unused(modules, required)
+	if len(unused) != 1 || unused[0].String() != "github.com/spf13/cobra@v1.8.0" {
+		t.Errorf("Unused() = %v", unused)
+	}
+}
+
+func TestPrunedGraph(t *testing.T) {
+	for v, want := range map[string]bool{"": false, "1.16": false, "1.17": true, "1.21.0": true, "1.9": false, "2.0": true} {
+		if got := prunedGraph(v); got != want {
+			t.Errorf("prunedGraph(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Module struct {
+	Path    string
+	Version string
+	Dir     string
+	Size    int64
+}
+
+func (m *Module) String() string { return m.Path + "@" + m.Version }
+
+const downloadDir = "cache"
+
+// the toolchains downloaded by GOTOOLCHAIN are not required by any go.mod
+const toolchainModule = "golang.org/toolchain"
+
+func ScanModules(dir string) ([]*Module, error) {
+	var modules []*Module
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || name == dir {
+			return nil
+		}
+		if filepath.Dir(name) == dir && entry.Name() == downloadDir {
+			return filepath.SkipDir
+		}
+		escapedPath, escapedVersion, ok := strings.Cut(entry.Name(), "@")
+		if !ok {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, filepath.Join(filepath.Dir(name), escapedPath))
+		path, err := unescape(filepath.ToSlash(rel))
+		if err != nil {
+			return filepath.SkipDir
+		}
+		version, err := unescape(escapedVersion)
+		if err != nil {
+			return filepath.SkipDir
+		}
+		usage, err := DirUsage(name)
+		if err != nil {
+			return err
+		}
+		modules = append(modules, &Module{Path: path, Version: version, Dir: name, Size: usage.Size})
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read module cache: %w", err)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Size != modules[j].Size {
+			return modules[i].Size > modules[j].Size
+		}
+		return modules[i].String() < modules[j].String()
+	})
+	return modules, nil
+}
+
+// an upper-case letter is written as ! followed by the lower-case letter
+func unescape(s string) (string, error) {
+	var sb strings.Builder
+	bang := false
+	for _, r := range s {
+		switch {
+		case bang:
+			if !unicode.IsLower(r) {
+				return "", fmt.Errorf("invalid escaped path `%s`", s)
+			}
+			sb.WriteRune(unicode.ToUpper(r))
+			bang = false
+		case r == '!':
+			bang = true
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if bang {
+		return "", fmt.Errorf("invalid escaped path `%s`", s)
+	}
+	return sb.String(), nil
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			sb.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+var skippedRootDirs = map[string]bool{".git": true, "vendor": true, "node_modules": true, "testdata": true}
+
+type goModFile struct {
+	Go      string
+	Require []struct {
+		Path    string
+		Version string
+	}
+	Replace []struct {
+		New struct {
+			Path    string
+			Version string
+		}
+	}
+}
+
+// before go 1.17, go.mod doesn't list the indirect dependencies, the module
+// graph of these go.mod files is loaded from the module cache instead
+func Required(ctx context.Context, roots []string, modCache string) (map[string]bool, int, error) {
+	required := make(map[string]bool)
+	var files int
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if name != root && (skippedRootDirs[entry.Name()] || name == modCache) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.Name() != "go.mod" {
+				return nil
+			}
+			output, err := util.ExecResult(ctx, "go", []string{"mod", "edit", "-json", name}, nil)
+			if err != nil {
+				return err
+			}
+			var mod goModFile
+			if err = json.Unmarshal(output, &mod); err != nil {
+				return fmt.Errorf("could not parse %s: %w", name, err)
+			}
+			if !prunedGraph(mod.Go) {
+				if err = requiredGraph(ctx, filepath.Dir(name), required); err != nil {
+					return err
+				}
+			}
+			for _, r := range mod.Require {
+				required[r.Path+"@"+r.Version] = true
+			}
+			for _, r := range mod.Replace {
+				if r.New.Version != "" {
+					required[r.New.Path+"@"+r.New.Version] = true
+				}
+			}
+			files++
+			return nil
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("could not search go.mod files in %s: %w", root, err)
+		}
+	}
+	return required, files, nil
+}
+
+func prunedGraph(goVersion string) bool {
+	major, minor, _ := strings.Cut(goVersion, ".")
+	minor, _, _ = strings.Cut(minor, ".")
+	m, err1 := strconv.Atoi(major)
+	n, err2 := strconv.Atoi(minor)
+	if err1 != nil || err2 != nil {
+		// a go.mod without a go directive is treated as go 1.16
+		return false
+	}
+	return m > 1 || m == 1 && n >= 17
+}
+
+// resolved offline, the graph is incomplete if a go.mod was removed from the
+// download cache. go may need to update an old go.mod to load it, so it loads
+// a copy and the scanned modules are never modified.
+func requiredGraph(ctx context.Context, dir string, required map[string]bool) error {
+	tmpDir, err := os.MkdirTemp("", "catgo-modgraph-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	modFile := filepath.Join(tmpDir, "go.mod")
+	for _, name := range []string{"go.mod", "go.sum"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) && name == "go.sum" {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+		if err = os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			return fmt.Errorf("could not copy %s: %w", name, err)
+		}
+	}
+
+	args := []string{"-C", dir, "list", "-mod=mod", "-modfile=" + modFile, "-m", "-f", "{{.Path}}@{{.Version}}{{with .Replace}} {{.Path}}@{{.Version}}{{end}}", "all"}
+	output, err := util.ExecResult(ctx, "go", args, []string{"GOFLAGS=", "GOWORK=off", "GOPROXY=off"})
+	if err != nil {
+		return fmt.Errorf("could not load the module graph of %s, which is older than go 1.17: %w", dir, err)
+	}
+	for _, module := range strings.Fields(string(output)) {
+		if !strings.HasSuffix(module, "@") {
+			required[module] = true
+		}
+	}
+	return nil
+}
+
+func Unused(modules []*Module, required map[string]bool) []*Module {
+	var unused []*Module
+	for _, m := range modules {
+		if !required[m.String()] && m.Path != toolchainModule {
+			unused = append(unused, m)
+		}
+	}
+	return unused
+}
+
+// the .mod and .info files are kept, they are small and read to resolve the
+// module graph
+func RemoveModule(modCache string, m *Module) error {
+	if err := removeAll(m.Dir); err != nil {
+		return fmt.Errorf("could not remove %s: %w", m, err)
+	}
+	base := filepath.Join(modCache, downloadDir, "download", filepath.FromSlash(escape(m.Path)), "@v", escape(m.Version))
+	for _, ext := range []string{".zip", ".ziphash"} {
+		if err := os.Remove(base + ext); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not remove %s: %w", m, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package coverage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+func Convert(ctx context.Context, dir, profile string) error {
+	args := []string{"tool", "covdata", "textfmt", "-i=" + dir, "-o=" + profile}
+	if _, err := util.ExecResult(ctx, "go", args, nil); err != nil {
+		return fmt.Errorf("could not convert coverage data: %w", err)
+	}
+	return nil
+}
+
+func Percent(profile string) (float64, error) {
+	f, err := os.Open(profile)
+	if err != nil {
+		return 0, fmt.Errorf("could not open coverage profile: %w", err)
+	}
+	defer f.Close()
+
+	// the blocks of the merged runs are repeated, a block is covered if any
+	// run covered it
+	blocks := make(map[string]bool)
+	statements := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:10.2,12.3 2 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		numStmt, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		statements[fields[0]] = numStmt
+		blocks[fields[0]] = blocks[fields[0]] || count > 0
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, fmt.Errorf("could not read coverage profile: %w", err)
+	}
+	var total, covered int
+	for block, numStmt := range statements {
+		total += numStmt
+		if blocks[block] {
+			covered += numStmt
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(covered) * 100 / float64(total), nil
+}
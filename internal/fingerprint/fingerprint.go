@@ -0,0 +1,246 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+const StateDir = ".catgo"
+
+var relevantEnvPrefixes = []string{"GO", "CGO_", "CC=", "CXX=", "AR=", "PKG_CONFIG"}
+
+type Fingerprint struct {
+	Hash       string    `json:"hash"`
+	Output     string    `json:"output"`
+	OutputSize int64     `json:"output_size"`
+	OutputTime time.Time `json:"output_time"`
+}
+
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	Standard   bool
+	Module     *struct {
+		Path    string
+		Version string
+		Main    bool
+		Replace *struct {
+			Path    string
+			Version string
+		}
+	}
+	GoFiles    []string
+	CgoFiles   []string
+	CFiles     []string
+	CXXFiles   []string
+	HFiles     []string
+	SFiles     []string
+	SysoFiles  []string
+	EmbedFiles []string
+}
+
+// flags are the go build flags without -o and the package, a hermetic build
+// runs with env only, as the go commands of the fingerprint
+func Compute(ctx context.Context, pkg string, flags, env []string, hermetic bool) (string, error) {
+	listArgs := slices.Concat([]string{"list", "-deps", "-json"}, flags, []string{pkg})
+	output, err := goOutput(ctx, listArgs, env, hermetic)
+	if err != nil {
+		return "", err
+	}
+	goEnv, err := goOutput(ctx, []string{"env", "GOVERSION", "GOMODCACHE", "GOMOD", "GOWORK"}, env, hermetic)
+	if err != nil {
+		return "", err
+	}
+	values := strings.Split(strings.TrimRight(string(goEnv), "\n"), "\n")
+	if len(values) < 4 {
+		values = append(values, make([]string, 4-len(values))...)
+	}
+	goVersion, modCache := values[0], values[1]
+
+	h := sha256.New()
+	fmt.Fprintf(h, "go %s\n", goVersion)
+	fmt.Fprintf(h, "package %s\n", pkg)
+	for _, flag := range flags {
+		fmt.Fprintf(h, "flag %s\n", flag)
+	}
+	for _, kv := range RelevantEnv(env, hermetic) {
+		fmt.Fprintf(h, "env %s\n", kv)
+	}
+	// the go and godebug directives and the checksums of the dependencies
+	for _, name := range values[2:4] {
+		if name != "" && name != "off" && name != os.DevNull {
+			hashOptionalFile(h, name)
+			hashOptionalFile(h, strings.TrimSuffix(name, ".mod")+".sum")
+		}
+	}
+
+	pgo := pgoFlag(flags)
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", fmt.Errorf("could not parse go list output: %w", err)
+		}
+		if err := hashPackage(h, &p, modCache); err != nil {
+			return "", err
+		}
+		if pgo == "auto" && p.Name == "main" {
+			hashOptionalFile(h, filepath.Join(p.Dir, "default.pgo"))
+		}
+	}
+	if pgo != "auto" && pgo != "off" {
+		hashOptionalFile(h, pgo)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func goOutput(ctx context.Context, args, env []string, hermetic bool) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := util.Exec(ctx, "go", args, env, util.ExecIO{Stdout: &stdout, Stderr: io.Discard, ClearEnv: hermetic})
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// go build defaults to auto
+func pgoFlag(flags []string) string {
+	pgo := "auto"
+	for _, flag := range flags {
+		if v, ok := strings.CutPrefix(flag, "-pgo="); ok {
+			pgo = v
+		}
+	}
+	return pgo
+}
+
+func hashOptionalFile(h hash.Hash, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Fprintf(h, "nofile %s\n", path)
+		return
+	}
+	fmt.Fprintf(h, "file %s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+}
+
+// the later values win, a hermetic build doesn't see the variables of the process
+func RelevantEnv(env []string, hermetic bool) []string {
+	var result []string
+	if !hermetic {
+		for _, kv := range os.Environ() {
+			for _, prefix := range relevantEnvPrefixes {
+				if strings.HasPrefix(kv, prefix) {
+					result = append(result, kv)
+					break
+				}
+			}
+		}
+	}
+	result = append(result, env...)
+	// later values win, keep the order stable for the hash
+	seen := make(map[string]string)
+	for _, kv := range result {
+		key, _, _ := strings.Cut(kv, "=")
+		seen[key] = kv
+	}
+	result = result[:0]
+	for _, kv := range seen {
+		result = append(result, kv)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func hashPackage(h hash.Hash, p *listedPackage, modCache string) error {
+	fmt.Fprintf(h, "import %s\n", p.ImportPath)
+	switch {
+	case p.Standard:
+		// covered by the Go version
+		return nil
+	case p.Module != nil && modCache != "" && strings.HasPrefix(p.Dir, modCache+string(filepath.Separator)):
+		// the module cache is immutable, unlike vendor/ and the replaced directories
+		fmt.Fprintf(h, "module %s@%s\n", p.Module.Path, p.Module.Version)
+		return nil
+	}
+	var files []string
+	for _, list := range [][]string{p.GoFiles, p.CgoFiles, p.CFiles, p.CXXFiles, p.HFiles, p.SFiles, p.SysoFiles, p.EmbedFiles} {
+		files = append(files, list...)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		path := filepath.Join(p.Dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("could not stat file: %w", err)
+		}
+		fmt.Fprintf(h, "file %s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return nil
+}
+
+func stateFile(stateDir, output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return filepath.Join(stateDir, "fingerprint", hex.EncodeToString(sum[:8])+".json")
+}
+
+func IsFresh(stateDir, output, hash string) bool {
+	data, err := os.ReadFile(stateFile(stateDir, output))
+	if err != nil {
+		return false
+	}
+	var fp Fingerprint
+	if err = json.Unmarshal(data, &fp); err != nil {
+		return false
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return fp.Hash == hash && fp.Output == output &&
+		fp.OutputSize == info.Size() && fp.OutputTime.Equal(info.ModTime())
+}
+
+func Save(stateDir, output, hash string) error {
+	info, err := os.Stat(output)
+	if err != nil {
+		return fmt.Errorf("could not stat output: %w", err)
+	}
+	fp := Fingerprint{
+		Hash:       hash,
+		Output:     output,
+		OutputSize: info.Size(),
+		OutputTime: info.ModTime(),
+	}
+	data, err := json.MarshalIndent(&fp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode fingerprint: %w", err)
+	}
+	name := stateFile(stateDir, output)
+	if err = util.Mkdir(filepath.Dir(name)); err != nil {
+		return err
+	}
+	return util.WriteFile(name, data)
+}
+
+func Remove(stateDir, output string) {
+	os.Remove(stateFile(stateDir, output))
+}
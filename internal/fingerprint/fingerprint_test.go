@@ -0,0 +1,113 @@
+package fingerprint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// a later modification time, even on coarse file systems
+		mtime := time.Now().Add(time.Duration(len(content)) * time.Second)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/app\n\ngo 1.22\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	t.Chdir(dir)
+
+	ctx := context.Background()
+	compute := func(flags, env []string, hermetic bool) string {
+		t.Helper()
+		hash, err := Compute(ctx, "example.com/app", flags, env, hermetic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	env := []string{"CGO_ENABLED=0"}
+	hash := compute(nil, env, false)
+	if again := compute(nil, env, false); again != hash {
+		t.Fatalf("Compute() is not stable: %s != %s", again, hash)
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+		flags  []string
+		env    []string
+	}{
+		{name: "flag", flags: []string{"-trimpath"}, env: env},
+		{name: "env", env: []string{"CGO_ENABLED=1"}},
+		{name: "source", change: func() { write("main.go", "package main\n\nfunc main() { println() }\n") }, env: env},
+		{name: "new source", change: func() { write("util.go", "package main\n") }, env: env},
+		{name: "go directive", change: func() { write("go.mod", "module example.com/app\n\ngo 1.23\n") }, env: env},
+		{name: "godebug", change: func() { write("go.mod", "module example.com/app\n\ngo 1.23\n\ngodebug default=go1.21\n") }, env: env},
+		{name: "go.sum", change: func() { write("go.sum", "") }, env: env},
+	}
+	for _, tt := range tests {
+		if tt.change != nil {
+			tt.change()
+		}
+		stale := compute(tt.flags, tt.env, false)
+		if stale == hash {
+			t.Errorf("Compute() after a %s change is unchanged", tt.name)
+		}
+		hash = compute(nil, env, false)
+	}
+
+	// a hermetic build doesn't see the environment of the process
+	hermeticEnv := []string{"PATH=" + os.Getenv("PATH"), "HOME=" + os.Getenv("HOME"), "CGO_ENABLED=0"}
+	hermetic := compute(nil, hermeticEnv, true)
+	t.Setenv("GODEBUG", "panicnil=1")
+	if compute(nil, env, false) == hash {
+		t.Error("Compute() ignores GODEBUG of the process")
+	}
+	if compute(nil, hermeticEnv, true) != hermetic {
+		t.Error("Compute() of a hermetic build depends on GODEBUG of the process")
+	}
+}
+
+func TestIsFresh(t *testing.T) {
+	stateDir := t.TempDir()
+	output := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(output, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if IsFresh(stateDir, output, "abc") {
+		t.Fatal("IsFresh() without a fingerprint")
+	}
+	if err := Save(stateDir, output, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsFresh(stateDir, output, "abc") {
+		t.Error("IsFresh() = false after Save()")
+	}
+	if IsFresh(stateDir, output, "def") {
+		t.Error("IsFresh() = true with another hash")
+	}
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(output, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if IsFresh(stateDir, output, "abc") {
+		t.Error("IsFresh() = true after the output was modified")
+	}
+	Remove(stateDir, output)
+	if err := os.Chtimes(output, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if IsFresh(stateDir, output, "abc") {
+		t.Error("IsFresh() = true after Remove()")
+	}
+}
@@ -0,0 +1,135 @@
+package generate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+const (
+	generatePrefix = "//go:generate "
+	// inputsPrefix and outputsPrefix declare the globs of the inputs and the
+	// outputs of the next //go:generate directive, relative to the package.
+	inputsPrefix  = "//catgo:inputs "
+	outputsPrefix = "//catgo:outputs "
+)
+
+type Directive struct {
+	Package string
+	// where the command runs
+	Dir     string
+	File    string
+	Line    int
+	Text    string
+	Inputs  []string
+	Outputs []string
+}
+
+func (d *Directive) Command() string {
+	return strings.TrimPrefix(d.Text, generatePrefix)
+}
+
+type listedPackage struct {
+	ImportPath   string
+	Dir          string
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+func Find(ctx context.Context, pattern string, tags []string) ([]*Directive, error) {
+	args := []string{"list", "-e", "-json=ImportPath,Dir,GoFiles,CgoFiles,TestGoFiles,XTestGoFiles"}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+	output, err := util.ExecResult(ctx, "go", append(args, pattern), nil)
+	if err != nil {
+		return nil, err
+	}
+	var directives []*Directive
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not parse go list output: %w", err)
+		}
+		for _, files := range [][]string{p.GoFiles, p.CgoFiles, p.TestGoFiles, p.XTestGoFiles} {
+			for _, name := range files {
+				found, err := parseFile(filepath.Join(p.Dir, name))
+				if err != nil {
+					return nil, err
+				}
+				for _, d := range found {
+					d.Package, d.Dir = p.ImportPath, p.Dir
+				}
+				directives = append(directives, found...)
+			}
+		}
+	}
+	return directives, nil
+}
+
+func parseFile(name string) ([]*Directive, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	defer f.Close()
+
+	var directives []*Directive
+	var inputs, outputs []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		switch {
+		case strings.HasPrefix(text, inputsPrefix):
+			inputs = append(inputs, strings.Fields(strings.TrimPrefix(text, inputsPrefix))...)
+			continue
+		case strings.HasPrefix(text, outputsPrefix):
+			outputs = append(outputs, strings.Fields(strings.TrimPrefix(text, outputsPrefix))...)
+			continue
+		case strings.HasPrefix(text, generatePrefix):
+			command := strings.TrimSpace(strings.TrimPrefix(text, generatePrefix))
+			// the -command aliases are defined for the other directives of the file
+			if command != "" && !strings.HasPrefix(command, "-command ") {
+				directives = append(directives, &Directive{
+					File:    name,
+					Line:    line,
+					Text:    text,
+					Inputs:  inputs,
+					Outputs: outputs,
+				})
+			}
+		}
+		inputs, outputs = nil, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read file %s: %w", name, err)
+	}
+	return directives, nil
+}
+
+// go generate runs the same directive of another file of the package too
+func (d *Directive) Run(ctx context.Context, tags []string, execIO util.ExecIO) error {
+	// -run filters the -command definitions too
+	run := `^(//go:generate -command .*|` + regexp.QuoteMeta(d.Text) + `[ \t]*)$`
+	args := []string{"generate", "-run", run}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+	return util.Exec(ctx, "go", append(args, d.Package), nil, execIO)
+}
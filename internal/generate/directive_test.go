@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "gen.go")
+	content := `package gen
+
+//go:generate -command yacc go tool yacc
+//go:generate yacc -o parser.go parser.y
+
+//catgo:inputs *.proto
+//catgo:inputs schema/
+//catgo:outputs *.pb.go
+//go:generate protoc --go_out=. api.proto
+
+//catgo:inputs ignored.txt
+
+//go:generate stringer -type=Kind
+//go:generate
+// go:generate not a directive
+`
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	directives, err := parseFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Directive{
+		{File: name, Line: 4, Text: "//go:generate yacc -o parser.go parser.y"},
+		{
+			File:    name,
+			Line:    9,
+			Text:    "//go:generate protoc --go_out=. api.proto",
+			Inputs:  []string{"*.proto", "schema/"},
+			Outputs: []string{"*.pb.go"},
+		},
+		// the inputs only apply to the directive right below them
+		{File: name, Line: 13, Text: "//go:generate stringer -type=Kind"},
+	}
+	if !reflect.DeepEqual(directives, want) {
+		for _, d := range directives {
+			t.Logf("%+v", *d)
+		}
+		t.Fatalf("parseFile() returned %d directives, want %d", len(directives), len(want))
+	}
+	if command := directives[1].Command(); command != "protoc --go_out=. api.proto" {
+		t.Errorf("Command() = %q", command)
+	}
+}
@@ -0,0 +1,188 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Record struct {
+	InputHash string            `json:"input_hash"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+}
+
+type State struct {
+	Records map[string]*Record `json:"records"`
+}
+
+// the line is not part of the key to keep the record of a directive when
+// the lines above it change
+func (d *Directive) Key(moduleDir string) string {
+	rel, err := filepath.Rel(moduleDir, d.File)
+	if err != nil {
+		rel = d.File
+	}
+	return filepath.ToSlash(rel) + ":" + d.Command()
+}
+
+func LoadState(name string) (*State, error) {
+	st := &State{Records: make(map[string]*Record)}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read generate state: %w", err)
+	}
+	if err = json.Unmarshal(data, st); err != nil || st.Records == nil {
+		// an invalid state reruns everything
+		return &State{Records: make(map[string]*Record)}, nil
+	}
+	return st, nil
+}
+
+func (st *State) Save(name string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode generate state: %w", err)
+	}
+	if err = util.Mkdir(filepath.Dir(name)); err != nil {
+		return err
+	}
+	return util.WriteFile(name, data)
+}
+
+func (r *Record) UpToDate(inputHash string, outputs map[string]string) bool {
+	if r == nil || r.InputHash != inputHash || len(r.Outputs) != len(outputs) {
+		return false
+	}
+	for name, hash := range outputs {
+		if r.Outputs[name] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Directive) InputHash() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "command %s\n", d.Command())
+	fmt.Fprintf(h, "host %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	files, err := d.match(d.Inputs)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range append([]string{d.File}, files...) {
+		sum, err := util.FileSHA256(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file %s %s\n", name, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (d *Directive) OutputHashes() (map[string]string, error) {
+	files, err := d.match(d.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(files))
+	for _, name := range files {
+		if hashes[name], err = util.FileSHA256(name); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+func (d *Directive) match(globs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, glob := range globs {
+		if !filepath.IsAbs(glob) {
+			glob = filepath.Join(d.Dir, glob)
+		}
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid glob `%s`: %w", d.File, d.Line, glob, err)
+		}
+		for _, match := range matches {
+			err = filepath.WalkDir(match, func(name string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() {
+					seen[name] = true
+				}
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("could not read %s: %w", match, err)
+			}
+		}
+	}
+	files := make([]string, 0, len(seen))
+	for name := range seen {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+var skippedDirs = map[string]bool{".git": true, ".catgo": true, "bin": true, "target": true, "dist": true}
+
+func SnapshotTree(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if filepath.Dir(name) == dir && skippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err = io.Copy(h, f); err != nil {
+			return err
+		}
+		files[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read module tree: %w", err)
+	}
+	return files, nil
+}
+
+func ChangedFiles(before, after map[string]string) []string {
+	var changed []string
+	for name, hash := range after {
+		if before[name] != hash {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
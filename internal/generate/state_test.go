@@ -0,0 +1,128 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("gen.go", "package gen\n")
+	write("api.proto", "syntax = \"proto3\";\n")
+	write("schema/v1.json", "{}\n")
+	write("api.pb.go", "package gen\n")
+	d := &Directive{
+		Dir:     dir,
+		File:    filepath.Join(dir, "gen.go"),
+		Line:    3,
+		Text:    "//go:generate protoc api.proto",
+		Inputs:  []string{"*.proto", "schema"},
+		Outputs: []string{"*.pb.go"},
+	}
+
+	// the directive runs once and records its inputs and outputs
+	st, err := LoadState(filepath.Join(dir, ".catgo", "generate.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func() bool {
+		t.Helper()
+		inputHash, err := d.InputHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs, err := d.OutputHashes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := d.Key(dir)
+		if st.Records[key].UpToDate(inputHash, outputs) {
+			return true
+		}
+		st.Records[key] = &Record{InputHash: inputHash, Outputs: outputs}
+		return false
+	}
+	if check() {
+		t.Fatal("UpToDate() without a record")
+	}
+	if !check() {
+		t.Fatal("UpToDate() = false after the directive ran")
+	}
+	if key := d.Key(dir); key != "gen.go:protoc api.proto" {
+		t.Errorf("Key() = %q", key)
+	}
+
+	// the state survives a reload
+	stateFile := filepath.Join(dir, ".catgo", "generate.json")
+	if err = st.Save(stateFile); err != nil {
+		t.Fatal(err)
+	}
+	if st, err = LoadState(stateFile); err != nil {
+		t.Fatal(err)
+	}
+	if !check() {
+		t.Fatal("UpToDate() = false after LoadState()")
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+	}{
+		{name: "input", change: func() { write("api.proto", "syntax = \"proto2\";\n") }},
+		{name: "input in a directory", change: func() { write("schema/v2.json", "{}\n") }},
+		{name: "source file", change: func() { write("gen.go", "package gen\n\n// edited\n") }},
+		{name: "output", change: func() { write("api.pb.go", "package gen\n\n// edited\n") }},
+		{name: "new output", change: func() { write("extra.pb.go", "package gen\n") }},
+		{name: "removed output", change: func() { os.Remove(filepath.Join(dir, "extra.pb.go")) }},
+		{name: "command", change: func() { d.Text += " --strict" }},
+	}
+	for _, tt := range tests {
+		tt.change()
+		if check() {
+			t.Errorf("UpToDate() after a %s change", tt.name)
+		}
+		if !check() {
+			t.Errorf("UpToDate() = false after rerunning for a %s change", tt.name)
+		}
+	}
+
+	// a change outside the declared inputs doesn't rerun the directive
+	write("README.md", "docs\n")
+	if !check() {
+		t.Error("UpToDate() = false after a change of an undeclared file")
+	}
+}
+
+func TestLoadInvalidState(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "generate.json")
+	if err := os.WriteFile(name, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	st, err := LoadState(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(st.Records, map[string]*Record{}) {
+		t.Errorf("LoadState() = %+v, want no records", st.Records)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	before := map[string]string{"a.go": "1", "b.go": "2", "c.go": "3"}
+	after := map[string]string{"a.go": "1", "b.go": "4", "d.go": "5"}
+	if changed := ChangedFiles(before, after); !reflect.DeepEqual(changed, []string{"b.go", "c.go", "d.go"}) {
+		t.Errorf("ChangedFiles() = %v", changed)
+	}
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Feature struct {
+	Tags    []string `toml:"tags"`
+	Enables []string `toml:"enables"`
+	Default bool     `toml:"default"`
+}
+
+func (m *Manifest) FeatureNames() []string {
+	return sortedKeys(m.Features)
+}
+
+func (m *Manifest) ResolveFeatures(selected []string, all, noDefault bool) (features, tags []string, err error) {
+	var roots []string
+	switch {
+	case all:
+		roots = m.FeatureNames()
+	default:
+		if !noDefault {
+			for _, name := range m.FeatureNames() {
+				if m.Features[name].Default {
+					roots = append(roots, name)
+				}
+			}
+		}
+		roots = append(roots, selected...)
+	}
+
+	enabled := make(map[string]bool)
+	var enable func(name, parent string) error
+	enable = func(name, parent string) error {
+		if enabled[name] {
+			return nil
+		}
+		feature, ok := m.Features[name]
+		if !ok {
+			if parent != "" {
+				return fmt.Errorf("feature `%s` enables undefined feature `%s`", parent, name)
+			}
+			return fmt.Errorf("feature `%s` is not defined in %s", name, FileName)
+		}
+		enabled[name] = true
+		for _, tag := range feature.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		for _, child := range feature.Enables {
+			if err := enable(child, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range roots {
+		if err = enable(name, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	features = sortedKeys(enabled)
+	return features, tags, nil
+}
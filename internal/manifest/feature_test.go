@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveFeatures(t *testing.T) {
+	m := &Manifest{Features: map[string]Feature{
+		"assets":  {Tags: []string{"embed_assets"}, Default: true},
+		"sqlite":  {Tags: []string{"sqlite", "cgo_sqlite"}},
+		"full":    {Tags: []string{"full"}, Enables: []string{"sqlite", "metrics"}},
+		"metrics": {Tags: []string{"metrics", "sqlite"}, Enables: []string{"full"}},
+		"broken":  {Enables: []string{"missing"}},
+	}}
+	tests := []struct {
+		selected  []string
+		all       bool
+		noDefault bool
+		features  []string
+		tags      []string
+		wantErr   string
+	}{
+		{features: []string{"assets"}, tags: []string{"embed_assets"}},
+		{noDefault: true},
+		{selected: []string{"sqlite"}, noDefault: true, features: []string{"sqlite"}, tags: []string{"sqlite", "cgo_sqlite"}},
+		// enables are followed transitively, cycles and duplicate tags are ignored
+		{
+			selected:  []string{"full"},
+			noDefault: true,
+			features:  []string{"full", "metrics", "sqlite"},
+			tags:      []string{"full", "sqlite", "cgo_sqlite", "metrics"},
+		},
+		{
+			selected: []string{"sqlite", "sqlite"},
+			features: []string{"assets", "sqlite"},
+			tags:     []string{"embed_assets", "sqlite", "cgo_sqlite"},
+		},
+		{selected: []string{"nope"}, wantErr: "feature `nope` is not defined"},
+		{selected: []string{"broken"}, wantErr: "feature `broken` enables undefined feature `missing`"},
+		{all: true, wantErr: "enables undefined feature `missing`"},
+	}
+	for _, tt := range tests {
+		features, tags, err := m.ResolveFeatures(tt.selected, tt.all, tt.noDefault)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveFeatures(%v, %v, %v) = %v, want %q", tt.selected, tt.all, tt.noDefault, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveFeatures(%v, %v, %v) = %v", tt.selected, tt.all, tt.noDefault, err)
+			continue
+		}
+		if !slices.Equal(features, tt.features) {
+			t.Errorf("ResolveFeatures(%v, %v, %v) features = %v, want %v", tt.selected, tt.all, tt.noDefault, features, tt.features)
+		}
+		if !slices.Equal(tags, tt.tags) {
+			t.Errorf("ResolveFeatures(%v, %v, %v) tags = %v, want %v", tt.selected, tt.all, tt.noDefault, tags, tt.tags)
+		}
+	}
+
+	delete(m.Features, "broken")
+	features, _, err := m.ResolveFeatures(nil, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"assets", "full", "metrics", "sqlite"}; !slices.Equal(features, want) {
+		t.Errorf("ResolveFeatures(all) = %v, want %v", features, want)
+	}
+}
@@ -0,0 +1,187 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const FileName = "catgo.toml"
+
+type Manifest struct {
+	Package  PackageConfig      `toml:"package"`
+	Build    BuildConfig        `toml:"build"`
+	Test     TestConfig         `toml:"test"`
+	Profiles map[string]Profile `toml:"profile"`
+	Features map[string]Feature `toml:"features"`
+	Dist     DistConfig         `toml:"dist"`
+	Image    ImageConfig        `toml:"image"`
+	// keyed by <os>-<arch>, e.g. wasip1-wasm
+	Targets map[string]TargetConfig `toml:"target"`
+
+	// empty if no manifest exists
+	Path string `toml:"-"`
+}
+
+type PackageConfig struct {
+	// relative to the module root
+	Main string `toml:"main"`
+	// default to the last element of the module path
+	Output string `toml:"output"`
+	// relative to the module root
+	Build string `toml:"build"`
+}
+
+type BuildConfig struct {
+	Release bool   `toml:"release"`
+	Profile string `toml:"profile"`
+	// sets CGO_ENABLED explicitly when not nil
+	CGO    *bool `toml:"cgo"`
+	Vendor bool  `toml:"vendor"`
+	// Layout is the output layout: target (default) for target/<profile>/<os>-<arch>/,
+	// bin for the flat bin/ directory.
+	Layout string `toml:"layout"`
+	// keyed by the fully qualified variable name
+	Set map[string]string `toml:"set"`
+	// relative to the module root, default to the main package
+	StampPackage string `toml:"stamp-package"`
+}
+
+type DistConfig struct {
+	// relative to the module root
+	Dir     string   `toml:"dir"`
+	Profile string   `toml:"profile"`
+	Targets []string `toml:"targets"`
+	// globs relative to the module root
+	Include []string `toml:"include"`
+}
+
+type ImageConfig struct {
+	Name string `toml:"name"`
+	// relative to the module root
+	Base       string            `toml:"base"`
+	Targets    []string          `toml:"targets"`
+	Path       string            `toml:"path"`
+	Entrypoint []string          `toml:"entrypoint"`
+	Cmd        []string          `toml:"cmd"`
+	Env        map[string]string `toml:"env"`
+	Labels     map[string]string `toml:"labels"`
+	Workdir    string            `toml:"workdir"`
+	User       string            `toml:"user"`
+	Ports      []string          `toml:"ports"`
+}
+
+type TargetConfig struct {
+	// the binary and its arguments are appended to the runner
+	Runner []string `toml:"runner"`
+	// merged with the profile env by key
+	Env     map[string]string `toml:"env"`
+	Ldflags []string          `toml:"ldflags"`
+	Tags    []string          `toml:"tags"`
+}
+
+type TestConfig struct {
+	Package  string   `toml:"package"`
+	Race     bool     `toml:"race"`
+	Verbose  bool     `toml:"verbose"`
+	FailFast bool     `toml:"fail-fast"`
+	FullPath bool     `toml:"full-path"`
+	Count    int      `toml:"count"`
+	Timeout  Duration `toml:"timeout"`
+	Cpu      []string `toml:"cpu"`
+}
+
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+type Size int64
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+func ParseSize(s string) (Size, error) {
+	number, factor := strings.TrimSpace(s), int64(1)
+	for _, unit := range sizeUnits {
+		if v, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, factor = strings.TrimSpace(v), unit.factor
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size `%s`", s)
+	}
+	return Size(v * float64(factor)), nil
+}
+
+func (s *Size) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case int64:
+		*s = Size(v)
+		return nil
+	case string:
+		size, err := ParseSize(v)
+		if err != nil {
+			return err
+		}
+		*s = size
+		return nil
+	}
+	return fmt.Errorf("invalid size `%v`", v)
+}
+
+func Load(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Manifest{}, nil
+		}
+		return nil, fmt.Errorf("could not read %s: %w", FileName, err)
+	}
+	var m Manifest
+	md, err := toml.Decode(string(data), &m)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("could not parse %s: unknown key `%s`", path, undecoded[0])
+	}
+	m.Path = path
+	return &m, nil
+}
+
+func (b *BuildConfig) SetVariables() []string {
+	names := sortedKeys(b.Set)
+	vars := make([]string, 0, len(names))
+	for _, name := range names {
+		vars = append(vars, name+"="+b.Set[name])
+	}
+	return vars
+}
@@ -0,0 +1,74 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(*Manifest) bool
+		wantErr string
+	}{
+		{
+			name:  "missing",
+			check: func(m *Manifest) bool { return m.Path == "" && m.Profiles == nil },
+		},
+		{
+			name: "valid",
+			content: `
+[package]
+main = "./cmd/app"
+
+[build]
+release = true
+set = { "main.mode" = "prod" }
+
+[test]
+timeout = "5m"
+
+[profile.fast]
+inherits = "release"
+max-size = "12MiB"
+`,
+			check: func(m *Manifest) bool {
+				return m.Package.Main == "./cmd/app" && m.Build.Release &&
+					m.Build.SetVariables()[0] == "main.mode=prod" &&
+					m.Test.Timeout.Duration == 5*time.Minute &&
+					m.Profiles["fast"].Inherits == ProfileRelease &&
+					m.Profiles["fast"].MaxSize == 12<<20
+			},
+		},
+		{name: "unknown key", content: "[build]\nreleas = true\n", wantErr: "unknown key `build.releas`"},
+		{name: "unknown table", content: "[bulid]\nrelease = true\n", wantErr: "unknown key `bulid`"},
+		{name: "invalid size", content: "[profile.fast]\nmax-size = \"12XB\"\n", wantErr: "invalid size"},
+		{name: "invalid toml", content: "[build\n", wantErr: "could not parse"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.content != "" {
+			if err := os.WriteFile(filepath.Join(dir, FileName), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		m, err := Load(dir)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Load() = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Load() = %v", tt.name, err)
+			continue
+		}
+		if !tt.check(m) {
+			t.Errorf("%s: Load() = %+v", tt.name, m)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package manifest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+const (
+	ProfileDev     = "dev"
+	ProfileRelease = "release"
+)
+
+// unset fields are inherited from the parent profile, set fields replace the
+// parent's value except Env which is merged by key
+type Profile struct {
+	// custom profiles inherit from dev by default
+	Inherits string `toml:"inherits"`
+	Trimpath *bool  `toml:"trimpath"`
+	// -ldflags "-s -w"
+	Strip *bool `toml:"strip"`
+	Race  *bool `toml:"race"`
+	Cover *bool `toml:"cover"`
+	// relative to the module root
+	CoverPkg     []string          `toml:"coverpkg"`
+	Ldflags      []string          `toml:"ldflags"`
+	Gcflags      string            `toml:"gcflags"`
+	Asmflags     string            `toml:"asmflags"`
+	Tags         []string          `toml:"tags"`
+	GoExperiment string            `toml:"goexperiment"`
+	Env          map[string]string `toml:"env"`
+	// auto, off or a profile file relative to the module root
+	PGO          string `toml:"pgo"`
+	Stamp        *bool  `toml:"stamp"`
+	RequireClean *bool  `toml:"require-clean"`
+	Static       *bool  `toml:"static"`
+	Hermetic     *bool  `toml:"hermetic"`
+	MaxSize      Size   `toml:"max-size"`
+
+	Name string `toml:"-"`
+}
+
+func builtinProfiles() map[string]Profile {
+	enabled := true
+	return map[string]Profile{
+		ProfileDev: {},
+		ProfileRelease: {
+			Trimpath: &enabled,
+			Strip:    &enabled,
+		},
+	}
+}
+
+func (m *Manifest) ProfileNames() []string {
+	names := make(map[string]struct{})
+	for name := range builtinProfiles() {
+		names[name] = struct{}{}
+	}
+	for name := range m.Profiles {
+		names[name] = struct{}{}
+	}
+	return sortedKeys(names)
+}
+
+func (m *Manifest) ResolveProfile(name string) (*Profile, error) {
+	builtins := builtinProfiles()
+	lookup := func(name string) (Profile, bool) {
+		if p, ok := m.Profiles[name]; ok {
+			if b, ok := builtins[name]; ok && p.Inherits == "" {
+				// user settings for a built-in profile extend the built-in one
+				return b.merge(p), true
+			}
+			if p.Inherits == "" && name != ProfileDev {
+				p.Inherits = ProfileDev
+			}
+			return p, true
+		}
+		p, ok := builtins[name]
+		return p, ok
+	}
+
+	var chain []Profile
+	seen := make(map[string]bool)
+	for current := name; current != ""; {
+		if seen[current] {
+			return nil, fmt.Errorf("profile `%s` has an inheritance cycle", name)
+		}
+		seen[current] = true
+		p, ok := lookup(current)
+		if !ok {
+			if current == name {
+				return nil, fmt.Errorf("profile `%s` is not defined", name)
+			}
+			return nil, fmt.Errorf("profile `%s` inherits from undefined profile `%s`", name, current)
+		}
+		chain = append(chain, p)
+		current = p.Inherits
+	}
+
+	var resolved Profile
+	for i := len(chain) - 1; i >= 0; i-- {
+		resolved = resolved.merge(chain[i])
+	}
+	resolved.Inherits = ""
+	resolved.Name = name
+	return &resolved, nil
+}
+
+func (p Profile) merge(child Profile) Profile {
+	if child.Trimpath != nil {
+		p.Trimpath = child.Trimpath
+	}
+	if child.Strip != nil {
+		p.Strip = child.Strip
+	}
+	if child.Race != nil {
+		p.Race = child.Race
+	}
+	if child.Stamp != nil {
+		p.Stamp = child.Stamp
+	}
+	if child.RequireClean != nil {
+		p.RequireClean = child.RequireClean
+	}
+	if child.Static != nil {
+		p.Static = child.Static
+	}
+	if child.Hermetic != nil {
+		p.Hermetic = child.Hermetic
+	}
+	if child.MaxSize > 0 {
+		p.MaxSize = child.MaxSize
+	}
+	if child.Cover != nil {
+		p.Cover = child.Cover
+	}
+	if child.CoverPkg != nil {
+		p.CoverPkg = child.CoverPkg
+	}
+	if child.Ldflags != nil {
+		p.Ldflags = child.Ldflags
+	}
+	if child.Gcflags != "" {
+		p.Gcflags = child.Gcflags
+	}
+	if child.Asmflags != "" {
+		p.Asmflags = child.Asmflags
+	}
+	if child.Tags != nil {
+		p.Tags = child.Tags
+	}
+	if child.PGO != "" {
+		p.PGO = child.PGO
+	}
+	if child.GoExperiment != "" {
+		p.GoExperiment = child.GoExperiment
+	}
+	if len(child.Env) > 0 {
+		env := maps.Clone(p.Env)
+		if env == nil {
+			env = make(map[string]string, len(child.Env))
+		}
+		maps.Copy(env, child.Env)
+		p.Env = env
+	}
+	p.Inherits = child.Inherits
+	return p
+}
+
+// the target env wins over the profile env
+func (p *Profile) WithTarget(t TargetConfig) *Profile {
+	merged := *p
+	if len(t.Ldflags) > 0 {
+		merged.Ldflags = append(slices.Clone(p.Ldflags), t.Ldflags...)
+	}
+	if len(t.Env) > 0 {
+		merged.Env = maps.Clone(p.Env)
+		if merged.Env == nil {
+			merged.Env = make(map[string]string, len(t.Env))
+		}
+		maps.Copy(merged.Env, t.Env)
+	}
+	return &merged
+}
+
+func IsSet(v *bool) bool { return v != nil && *v }
+
+func (p *Profile) EnvList() []string {
+	var env []string
+	for _, key := range sortedKeys(p.Env) {
+		env = append(env, key+"="+p.Env[key])
+	}
+	if p.GoExperiment != "" {
+		env = append(env, "GOEXPERIMENT="+p.GoExperiment)
+	}
+	return env
+}
+
+func (t TargetConfig) EnvList() []string {
+	var env []string
+	for _, key := range sortedKeys(t.Env) {
+		env = append(env, key+"="+t.Env[key])
+	}
+	return env
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveProfile(t *testing.T) {
+	enabled, disabled := true, false
+	m := &Manifest{Profiles: map[string]Profile{
+		"dev":     {Race: &enabled, Env: map[string]string{"A": "dev"}},
+		"release": {Strip: &disabled, Env: map[string]string{"B": "release"}},
+		"fast":    {Gcflags: "-N -l"},
+		"dist": {
+			Inherits: "release",
+			Ldflags:  []string{"-X main.dist=1"},
+			Env:      map[string]string{"B": "dist", "C": "dist"},
+		},
+		"ci":       {Inherits: "dist", Strip: &enabled, Tags: []string{"ci"}},
+		"loop-a":   {Inherits: "loop-b"},
+		"loop-b":   {Inherits: "loop-a"},
+		"orphan":   {Inherits: "missing"},
+		"selfloop": {Inherits: "selfloop"},
+	}}
+	tests := []struct {
+		name    string
+		want    Profile
+		wantErr string
+	}{
+		{name: "dev", want: Profile{Race: &enabled, Env: map[string]string{"A": "dev"}}},
+		// user settings extend the built-in release profile
+		{name: "release", want: Profile{Trimpath: &enabled, Strip: &disabled, Env: map[string]string{"B": "release"}}},
+		// custom profiles inherit from dev by default
+		{name: "fast", want: Profile{Race: &enabled, Gcflags: "-N -l", Env: map[string]string{"A": "dev"}}},
+		{name: "dist", want: Profile{
+			Trimpath: &enabled,
+			Strip:    &disabled,
+			Ldflags:  []string{"-X main.dist=1"},
+			Env:      map[string]string{"B": "dist", "C": "dist"},
+		}},
+		{name: "ci", want: Profile{
+			Trimpath: &enabled,
+			Strip:    &enabled,
+			Ldflags:  []string{"-X main.dist=1"},
+			Tags:     []string{"ci"},
+			Env:      map[string]string{"B": "dist", "C": "dist"},
+		}},
+		{name: "loop-a", wantErr: "profile `loop-a` has an inheritance cycle"},
+		{name: "selfloop", wantErr: "profile `selfloop` has an inheritance cycle"},
+		{name: "orphan", wantErr: "profile `orphan` inherits from undefined profile `missing`"},
+		{name: "bench", wantErr: "profile `bench` is not defined"},
+	}
+	for _, tt := range tests {
+		got, err := m.ResolveProfile(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveProfile(%s) = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveProfile(%s) = %v", tt.name, err)
+			continue
+		}
+		tt.want.Name = tt.name
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ResolveProfile(%s) = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+
+	// merging must not modify the env of the parent profiles
+	if _, err := m.ResolveProfile("dist"); err != nil {
+		t.Fatal(err)
+	}
+	if env := m.Profiles["release"].Env; !reflect.DeepEqual(env, map[string]string{"B": "release"}) {
+		t.Errorf("ResolveProfile(dist) modified the release env: %v", env)
+	}
+}
+
+func TestResolveBuiltinProfiles(t *testing.T) {
+	m := &Manifest{}
+	release, err := m.ResolveProfile(ProfileRelease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSet(release.Trimpath) || !IsSet(release.Strip) {
+		t.Errorf("ResolveProfile(release) = %+v, want trimpath and strip", release)
+	}
+	dev, err := m.ResolveProfile(ProfileDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*dev, Profile{Name: ProfileDev}) {
+		t.Errorf("ResolveProfile(dev) = %+v, want an empty profile", dev)
+	}
+	if names := m.ProfileNames(); !reflect.DeepEqual(names, []string{"dev", "release"}) {
+		t.Errorf("ProfileNames() = %v", names)
+	}
+}
@@ -0,0 +1,195 @@
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// for reproducible layers
+var epoch = time.Unix(0, 0)
+
+type Layer struct {
+	MediaType string
+	Digest    string
+	Size      int64
+	// DiffID is the digest of the uncompressed tarball.
+	DiffID    string
+	CreatedBy string
+}
+
+type File struct {
+	Path string
+	Src  string
+	Mode int64
+}
+
+func (l *Layout) FileLayer(files []File, createdBy string) (*Layer, error) {
+	b, err := l.createBlob()
+	if err != nil {
+		return nil, err
+	}
+	gw := gzip.NewWriter(b)
+	diffID := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(gw, diffID))
+	if err = writeFiles(tw, files); err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		b.abort()
+		return nil, fmt.Errorf("could not write layer: %w", err)
+	}
+	digest, size, err := l.commit(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Layer{
+		MediaType: MediaTypeLayerGzip,
+		Digest:    digest,
+		Size:      size,
+		DiffID:    "sha256:" + hex.EncodeToString(diffID.Sum(nil)),
+		CreatedBy: createdBy,
+	}, nil
+}
+
+func writeFiles(tw *tar.Writer, files []File) error {
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		for dir := path.Dir(path.Clean("/" + file.Path)); dir != "/"; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir[1:] + "/", Mode: 0755, ModTime: epoch}); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		if err := writeFile(tw, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(tw *tar.Writer, file File) error {
+	fp, err := os.Open(file.Src)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Clean("/" + file.Path)[1:],
+		Mode:     file.Mode,
+		Size:     info.Size(),
+		ModTime:  epoch,
+	}
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, fp)
+	return err
+}
+
+// the tarball is copied as is and validated while it's hashed
+func (l *Layout) TarLayer(name string) (*Layer, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read base layer: %w", err)
+	}
+	defer fp.Close()
+	b, err := l.createBlob()
+	if err != nil {
+		return nil, err
+	}
+	mediaType, diffID, err := copyTarball(b, fp)
+	if err != nil {
+		b.abort()
+		return nil, fmt.Errorf("base layer %s is not a tarball: %w", name, err)
+	}
+	digest, size, err := l.commit(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Layer{MediaType: mediaType, Digest: digest, Size: size, DiffID: diffID, CreatedBy: "base " + filepath.Base(name)}, nil
+}
+
+func copyTarball(w io.Writer, r io.Reader) (string, string, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	raw := io.TeeReader(br, w)
+	diffID := sha256.New()
+	mediaType, tarball := MediaTypeLayer, io.TeeReader(raw, diffID)
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(raw)
+		if err != nil {
+			return "", "", err
+		}
+		mediaType, tarball = MediaTypeLayerGzip, io.TeeReader(gr, diffID)
+	}
+	tr := tar.NewReader(tarball)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+	// the padding after the end of archive is part of the tarball
+	if _, err := io.Copy(io.Discard, tarball); err != nil {
+		return "", "", err
+	}
+	if _, err := io.Copy(io.Discard, raw); err != nil {
+		return "", "", err
+	}
+	return mediaType, "sha256:" + hex.EncodeToString(diffID.Sum(nil)), nil
+}
+
+func ParseReference(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, " \t\n@") {
+		return "", fmt.Errorf("invalid image name `%s`", name)
+	}
+	repository, ref := name, name
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		repository = name[:i]
+		if i == len(name)-1 {
+			return "", fmt.Errorf("invalid image name `%s`: empty tag", name)
+		}
+	} else {
+		ref += ":latest"
+	}
+	// the registry host may have upper-case letters, the path may not
+	if host, path, ok := strings.Cut(repository, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		repository = path
+	}
+	if repository != strings.ToLower(repository) {
+		return "", fmt.Errorf("invalid image name `%s`: the repository must be lower-case", name)
+	}
+	return ref, nil
+}
+
+func Tag(name string) string {
+	return name[strings.LastIndex(name, ":")+1:]
+}
@@ -0,0 +1,372 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+const (
+	MediaTypeIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeLayer     = "application/vnd.oci.image.layer.v1.tar"
+	MediaTypeLayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// read by skopeo, podman, containerd and docker load
+const (
+	AnnotationRefName        = "org.opencontainers.image.ref.name"
+	AnnotationContainerdName = "io.containerd.image.name"
+)
+
+const layoutFile = "oci-layout"
+
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Config struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+}
+
+type Image struct {
+	Platform Platform
+	Config   Config
+	Layers   []*Layer
+	Created  time.Time
+}
+
+type rootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+type history struct {
+	Created   time.Time `json:"created"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+type imageConfig struct {
+	Created      time.Time `json:"created"`
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Variant      string    `json:"variant,omitempty"`
+	Config       Config    `json:"config"`
+	RootFS       rootFS    `json:"rootfs"`
+	History      []history `json:"history"`
+}
+
+type manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        *Descriptor       `json:"config,omitempty"`
+	Layers        []*Descriptor     `json:"layers,omitempty"`
+	Manifests     []*Descriptor     `json:"manifests,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// the blobs are stored in a temporary directory until the layout is written
+type Layout struct {
+	dir   string
+	blobs map[string]int64
+	index []*Descriptor
+}
+
+// the layout must be closed to remove its blobs
+func NewLayout() (*Layout, error) {
+	dir, err := os.MkdirTemp("", "catgo-image-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	return &Layout{dir: dir, blobs: make(map[string]int64)}, nil
+}
+
+func (l *Layout) Close() error {
+	return os.RemoveAll(l.dir)
+}
+
+func (l *Layout) blobPath(digest string) string {
+	return filepath.Join(l.dir, strings.TrimPrefix(digest, "sha256:"))
+}
+
+type blob struct {
+	fp   *os.File
+	hash hash.Hash
+	size int64
+}
+
+func (l *Layout) createBlob() (*blob, error) {
+	fp, err := os.CreateTemp(l.dir, "blob-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create blob: %w", err)
+	}
+	return &blob{fp: fp, hash: sha256.New()}, nil
+}
+
+func (b *blob) Write(data []byte) (int, error) {
+	n, err := b.fp.Write(data)
+	b.hash.Write(data[:n])
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *blob) abort() {
+	b.fp.Close()
+	os.Remove(b.fp.Name())
+}
+
+func (l *Layout) commit(b *blob) (string, int64, error) {
+	if err := b.fp.Close(); err != nil {
+		os.Remove(b.fp.Name())
+		return "", 0, fmt.Errorf("could not write blob: %w", err)
+	}
+	digest := "sha256:" + hex.EncodeToString(b.hash.Sum(nil))
+	if err := os.Rename(b.fp.Name(), l.blobPath(digest)); err != nil {
+		return "", 0, fmt.Errorf("could not write blob: %w", err)
+	}
+	l.blobs[digest] = b.size
+	return digest, b.size, nil
+}
+
+func (l *Layout) addJSON(mediaType string, v any) (*Descriptor, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode %s: %w", mediaType, err)
+	}
+	b, err := l.createBlob()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = b.Write(data); err != nil {
+		b.abort()
+		return nil, fmt.Errorf("could not write blob: %w", err)
+	}
+	digest, size, err := l.commit(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{MediaType: mediaType, Digest: digest, Size: size}, nil
+}
+
+func (l *Layout) addImage(image *Image) (*Descriptor, error) {
+	config := imageConfig{
+		Created:      image.Created,
+		Architecture: image.Platform.Architecture,
+		OS:           image.Platform.OS,
+		Variant:      image.Platform.Variant,
+		Config:       image.Config,
+		RootFS:       rootFS{Type: "layers", DiffIDs: []string{}},
+	}
+	m := manifest{SchemaVersion: 2, MediaType: MediaTypeManifest}
+	for _, layer := range image.Layers {
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, layer.DiffID)
+		config.History = append(config.History, history{Created: image.Created, CreatedBy: layer.CreatedBy})
+		m.Layers = append(m.Layers, &Descriptor{MediaType: layer.MediaType, Digest: layer.Digest, Size: layer.Size})
+	}
+	var err error
+	if m.Config, err = l.addJSON(MediaTypeConfig, &config); err != nil {
+		return nil, err
+	}
+	desc, err := l.addJSON(MediaTypeManifest, &m)
+	if err != nil {
+		return nil, err
+	}
+	desc.Platform = &image.Platform
+	return desc, nil
+}
+
+// a multi-platform image is added as a nested index of the platform manifests
+func (l *Layout) Add(name string, images []*Image) (string, error) {
+	var manifests []*Descriptor
+	for _, image := range images {
+		desc, err := l.addImage(image)
+		if err != nil {
+			return "", err
+		}
+		manifests = append(manifests, desc)
+	}
+	desc := manifests[0]
+	if len(manifests) > 1 {
+		var err error
+		desc, err = l.addJSON(MediaTypeIndex, &manifest{SchemaVersion: 2, MediaType: MediaTypeIndex, Manifests: manifests})
+		if err != nil {
+			return "", err
+		}
+	}
+	named := *desc
+	named.Annotations = map[string]string{
+		AnnotationRefName:        Tag(name),
+		AnnotationContainerdName: name,
+	}
+	l.index = append(l.index, &named)
+	return desc.Digest, nil
+}
+
+func (l *Layout) files() ([]string, map[string][]byte, error) {
+	index, err := json.Marshal(&manifest{SchemaVersion: 2, MediaType: MediaTypeIndex, Manifests: l.index})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not encode index.json: %w", err)
+	}
+	metadata := map[string][]byte{
+		layoutFile:   []byte(`{"imageLayoutVersion":"1.0.0"}`),
+		"index.json": index,
+	}
+	names := []string{layoutFile, "index.json"}
+	for _, d := range slices.Sorted(maps.Keys(l.blobs)) {
+		names = append(names, "blobs/sha256/"+strings.TrimPrefix(d, "sha256:"))
+	}
+	return names, metadata, nil
+}
+
+func (l *Layout) open(name string, metadata map[string][]byte) (io.ReadCloser, int64, error) {
+	if data, ok := metadata[name]; ok {
+		return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+	}
+	digest := "sha256:" + path.Base(name)
+	fp, err := os.Open(l.blobPath(digest))
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not open blob: %w", err)
+	}
+	return fp, l.blobs[digest], nil
+}
+
+func (l *Layout) WriteDir(dir string) error {
+	if err := clearDir(dir); err != nil {
+		return err
+	}
+	names, metadata, err := l.files()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		if err = util.Mkdir(filepath.Dir(dst)); err != nil {
+			return err
+		}
+		if err = l.copyFile(name, metadata, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Layout) copyFile(name string, metadata map[string][]byte, dst string) error {
+	src, _, err := l.open(name, metadata)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fp, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("could not write image layout: %w", err)
+	}
+	if _, err = io.Copy(fp, src); err != nil {
+		fp.Close()
+		return fmt.Errorf("could not write image layout: %w", err)
+	}
+	if err = fp.Close(); err != nil {
+		return fmt.Errorf("could not write image layout: %w", err)
+	}
+	return nil
+}
+
+// refuses to replace a non-empty directory which is not an image layout
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) || err == nil && len(entries) == 0 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", dir, err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, layoutFile)); err != nil {
+		return fmt.Errorf("%s is not empty and is not an image layout", dir)
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("could not remove %s: %w", dir, err)
+	}
+	return nil
+}
+
+func (l *Layout) WriteTar(name string) (err error) {
+	names, metadata, err := l.files()
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("could not create image archive: %w", err)
+	}
+	defer func() {
+		if closeErr := fp.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	tw := tar.NewWriter(fp)
+	for _, dir := range []string{"blobs/", "blobs/sha256/"} {
+		if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir, Mode: 0755, ModTime: epoch}); err != nil {
+			return fmt.Errorf("could not write image archive %s: %w", name, err)
+		}
+	}
+	for _, file := range names {
+		if err = l.addTarFile(tw, file, metadata); err != nil {
+			return fmt.Errorf("could not write image archive %s: %w", name, err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return fmt.Errorf("could not write image archive %s: %w", name, err)
+	}
+	return nil
+}
+
+func (l *Layout) addTarFile(tw *tar.Writer, name string, metadata map[string][]byte) error {
+	src, size, err := l.open(name, metadata)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: size, ModTime: epoch}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
@@ -0,0 +1,70 @@
+package oci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"app", "app:latest"},
+		{"example.com/team/app:1.0", "example.com/team/app:1.0"},
+		{"localhost:5000/app", "localhost:5000/app:latest"},
+		{"Registry.Example.com/app:v2", "Registry.Example.com/app:v2"},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReference(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "App", "app:", "app@sha256:00", "team/App:1.0"} {
+		if _, err := ParseReference(in); err == nil {
+			t.Errorf("ParseReference(%q) succeeded", in)
+		}
+	}
+	if got := Tag("localhost:5000/app:1.0"); got != "1.0" {
+		t.Errorf("Tag() = %q", got)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app")
+	if err := os.WriteFile(src, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := NewLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer layout.Close()
+
+	files := []File{{Path: "/usr/local/bin/app", Src: src, Mode: 0755}}
+	a, err := layout.FileLayer(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := layout.FileLayer(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *a != *b {
+		t.Errorf("FileLayer() is not reproducible: %+v != %+v", a, b)
+	}
+
+	// the blob of the layer is used as a gzip-compressed base tarball
+	base, err := layout.TarLayer(layout.blobPath(a.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.MediaType != MediaTypeLayerGzip || base.Digest != a.Digest || base.DiffID != a.DiffID {
+		t.Errorf("TarLayer() = %+v, want the digests of %+v", base, a)
+	}
+	if _, err = layout.TarLayer(src); err == nil {
+		t.Error("TarLayer() accepted a file which is not a tarball")
+	}
+}
@@ -0,0 +1,98 @@
+package pgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+const DefaultFile = "default.pgo"
+
+// output can be one of the profiles
+func Merge(ctx context.Context, output string, profiles []string) error {
+	args := append([]string{"tool", "pprof", "-proto"}, profiles...)
+	data, err := util.ExecResult(ctx, "go", args, nil)
+	if err != nil {
+		return fmt.Errorf("could not merge profiles: %w", err)
+	}
+	return util.WriteFile(output, data)
+}
+
+type HotFunction struct {
+	Name string
+	// Flat is the percentage of the samples in the function itself.
+	Flat float64
+	// Cum is the percentage of the samples in the function and its callees.
+	Cum float64
+}
+
+func HotFunctions(ctx context.Context, profile string, n int) ([]HotFunction, error) {
+	args := []string{"tool", "pprof", "-top", fmt.Sprintf("-nodecount=%d", n), profile}
+	data, err := util.ExecResult(ctx, "go", args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not read profile: %w", err)
+	}
+	return parseTop(data), nil
+}
+
+// parseTop parses the rows of go tool pprof -top, e.g.
+//
+//	flat  flat%   sum%        cum   cum%
+//	1.20s 40.00% 40.00%      1.50s 50.00%  main.fib
+func parseTop(data []byte) []HotFunction {
+	var functions []HotFunction
+	var rows bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if !rows {
+			rows = len(fields) > 0 && fields[0] == "flat"
+			continue
+		}
+		if len(fields) < 6 {
+			continue
+		}
+		flat, err1 := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+		cum, err2 := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		name := strings.TrimSuffix(strings.Join(fields[5:], " "), " (inline)")
+		functions = append(functions, HotFunction{Name: name, Flat: flat, Cum: cum})
+	}
+	return functions
+}
+
+// Before is negative if the function was not hot
+type Change struct {
+	Name   string
+	Before float64
+	After  float64
+}
+
+func Compare(before, after []HotFunction, n int) []Change {
+	previous := make(map[string]float64, len(before))
+	for _, f := range before {
+		previous[f.Name] = f.Flat
+	}
+	sorted := append([]HotFunction(nil), after...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Flat > sorted[j].Flat })
+	var changes []Change
+	for _, f := range sorted {
+		if len(changes) == n || f.Flat == 0 {
+			break
+		}
+		flat, ok := previous[f.Name]
+		if !ok {
+			flat = -1
+		}
+		changes = append(changes, Change{Name: f.Name, Before: flat, After: f.Flat})
+	}
+	return changes
+}
@@ -0,0 +1,26 @@
+package pgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTop(t *testing.T) {
+	output := `File: demo.test
+Type: cpu
+Duration: 1.40s, Total samples = 1.20s (85.71%)
+Showing nodes accounting for 1.20s, 100% of 1.20s total
+      flat  flat%   sum%        cum   cum%
+     0.90s 75.00% 75.00%      0.90s 75.00%  example.com/demo.fib
+     0.20s 16.67% 91.67%      0.20s 16.67%  strings.Index (inline)
+     0.10s  8.33%   100%      1.20s   100%  testing.(*B).runN
+`
+	want := []HotFunction{
+		{Name: "example.com/demo.fib", Flat: 75, Cum: 75},
+		{Name: "strings.Index", Flat: 16.67, Cum: 16.67},
+		{Name: "testing.(*B).runN", Flat: 8.33, Cum: 100},
+	}
+	if got := parseTop([]byte(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTop() = %v, want %v", got, want)
+	}
+}
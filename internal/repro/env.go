@@ -0,0 +1,53 @@
+package repro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+const Flags = "-trimpath"
+
+// required to run the toolchain but don't affect the output
+var passEnv = []string{"PATH", "HOME", "USERPROFILE", "SYSTEMROOT", "LOCALAPPDATA", "APPDATA", "TMPDIR", "TEMP", "TMP"}
+
+// they locate the caches and the module sources which are verified by go.sum
+var goEnv = []string{"GOPATH", "GOMODCACHE", "GOCACHE", "GOPROXY", "GOSUMDB", "GOPRIVATE", "GONOPROXY", "GONOSUMDB", "GOINSECURE"}
+
+func Environ(ctx context.Context) ([]string, error) {
+	output, err := util.ExecResult(ctx, "go", append([]string{"env", "-json"}, goEnv...), nil)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]string
+	if err = json.Unmarshal(output, &values); err != nil {
+		return nil, fmt.Errorf("could not parse go env output: %w", err)
+	}
+	return scrub(os.Environ(), values), nil
+}
+
+func scrub(environ []string, values map[string]string) []string {
+	var env []string
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if slices.ContainsFunc(passEnv, func(name string) bool { return strings.EqualFold(name, key) }) {
+			env = append(env, kv)
+		}
+	}
+	for _, key := range goEnv {
+		if v := values[key]; v != "" {
+			env = append(env, key+"="+v)
+		}
+	}
+	return append(env,
+		"GOFLAGS="+Flags,
+		"GOTOOLCHAIN=local",
+		"GOENV=off",
+		"GOWORK=off",
+	)
+}
@@ -0,0 +1,35 @@
+package repro
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/gopher",
+		"GOFLAGS=-race",
+		"GOTOOLCHAIN=go1.99.0",
+		"CGO_CFLAGS=-O3",
+		"SECRET_TOKEN=x",
+	}
+	values := map[string]string{
+		"GOMODCACHE": "/home/gopher/go/pkg/mod",
+		"GOPROXY":    "https://proxy.golang.org,direct",
+		"GOPRIVATE":  "",
+	}
+	want := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/gopher",
+		"GOMODCACHE=/home/gopher/go/pkg/mod",
+		"GOPROXY=https://proxy.golang.org,direct",
+		"GOFLAGS=-trimpath",
+		"GOTOOLCHAIN=local",
+		"GOENV=off",
+		"GOWORK=off",
+	}
+	if got := scrub(environ, values); !slices.Equal(got, want) {
+		t.Errorf("scrub() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package repro
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const headers = "(headers)"
+
+// "(headers)" is returned if only the bytes outside of the sections differ
+func DiffSections(a, b string) ([]string, error) {
+	sumsA, err := sectionSums(a)
+	if err != nil {
+		return nil, err
+	}
+	sumsB, err := sectionSums(b)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, sum := range sumsA {
+		if other, ok := sumsB[name]; !ok || !bytes.Equal(sum, other) {
+			names = append(names, name)
+		}
+	}
+	for name := range sumsB {
+		if _, ok := sumsA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		names = append(names, headers)
+	}
+	return names, nil
+}
+
+type section struct {
+	name string
+	r    io.Reader
+}
+
+func sectionSums(name string) (map[string][]byte, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open binary: %w", err)
+	}
+	defer fp.Close()
+
+	sections, err := readSections(fp)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sections of %s: %w", name, err)
+	}
+	sums := make(map[string][]byte, len(sections))
+	for _, s := range sections {
+		h := sha256.New()
+		if _, err := io.Copy(h, s.r); err != nil {
+			return nil, fmt.Errorf("could not read section %s of %s: %w", s.name, name, err)
+		}
+		sums[s.name] = h.Sum(nil)
+	}
+	return sums, nil
+}
+
+func readSections(r io.ReaderAt) ([]section, error) {
+	var sections []section
+	if f, err := elf.NewFile(r); err == nil {
+		for _, s := range f.Sections {
+			if s.Type != elf.SHT_NOBITS && s.Type != elf.SHT_NULL {
+				sections = append(sections, section{s.Name, s.Open()})
+			}
+		}
+		return sections, nil
+	}
+	if f, err := macho.NewFile(r); err == nil {
+		for _, s := range f.Sections {
+			sections = append(sections, section{s.Seg + "," + s.Name, s.Open()})
+		}
+		return sections, nil
+	}
+	if f, err := pe.NewFile(r); err == nil {
+		for _, s := range f.Sections {
+			sections = append(sections, section{s.Name, s.Open()})
+		}
+		return sections, nil
+	}
+	return nil, errors.New("unsupported binary format")
+}
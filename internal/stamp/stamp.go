@@ -0,0 +1,118 @@
+package stamp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Info struct {
+	Version   string
+	GitCommit string
+	Dirty     bool
+	BuildTime time.Time
+	GoVersion string
+}
+
+var errNotGitRepository = errors.New("not a git repository")
+
+// outside a git repository the git related fields are "unknown"
+func Collect(ctx context.Context, dir string) (*Info, error) {
+	info := &Info{
+		Version:   "unknown",
+		GitCommit: "unknown",
+	}
+
+	var err error
+	if info.BuildTime, err = buildTime(); err != nil {
+		return nil, err
+	}
+
+	output, err := util.ExecResult(ctx, "go", []string{"env", "GOVERSION"}, nil)
+	if err != nil {
+		return nil, err
+	}
+	info.GoVersion = strings.TrimSpace(string(output))
+
+	if err = collectGit(ctx, dir, info); err != nil && !errors.Is(err, errNotGitRepository) {
+		return nil, err
+	}
+	return info, nil
+}
+
+func collectGit(ctx context.Context, dir string, info *Info) error {
+	git := func(args ...string) (string, error) {
+		output, err := util.ExecResult(ctx, "git", append([]string{"-C", dir}, args...), nil)
+		return strings.TrimSpace(string(output)), err
+	}
+	if _, err := git("rev-parse", "--git-dir"); err != nil {
+		return errNotGitRepository
+	}
+	// a repository without any commit has no HEAD
+	commit, err := git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return errNotGitRepository
+	}
+	info.GitCommit = commit
+
+	status, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return err
+	}
+	info.Dirty = status != ""
+
+	if version, err := git("describe", "--tags", "--always"); err == nil && version != "" {
+		info.Version = version
+	}
+	if info.Dirty {
+		info.Version += "-dirty"
+	}
+	return nil
+}
+
+func buildTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH `%s`: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+func (info *Info) Value(kind Kind) string {
+	switch kind {
+	case KindVersion:
+		return info.Version
+	case KindGitCommit:
+		return info.GitCommit
+	case KindDirty:
+		return strconv.FormatBool(info.Dirty)
+	case KindTreeState:
+		if info.Dirty {
+			return "dirty"
+		}
+		return "clean"
+	case KindBuildTime:
+		return info.BuildTime.Format(time.RFC3339)
+	case KindGoVersion:
+		return info.GoVersion
+	}
+	return ""
+}
+
+func (info *Info) SetVariables(vars []Variable) []string {
+	result := make([]string, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, v.Name+"="+info.Value(v.Kind))
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package stamp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Kind int
+
+const (
+	KindVersion Kind = iota + 1
+	KindGitCommit
+	KindDirty
+	KindTreeState
+	KindBuildTime
+	KindGoVersion
+)
+
+var variableKinds = map[string]Kind{
+	"version":      KindVersion,
+	"appversion":   KindVersion,
+	"gitversion":   KindVersion,
+	"gitcommit":    KindGitCommit,
+	"commit":       KindGitCommit,
+	"commithash":   KindGitCommit,
+	"revision":     KindGitCommit,
+	"gitrevision":  KindGitCommit,
+	"dirty":        KindDirty,
+	"gitdirty":     KindDirty,
+	"gittreestate": KindTreeState,
+	"buildtime":    KindBuildTime,
+	"builddate":    KindBuildTime,
+	"goversion":    KindGoVersion,
+}
+
+type Variable struct {
+	// Name is the fully qualified variable name, e.g. github.com/josexy/catgo/version.Version
+	Name string
+	Kind Kind
+}
+
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoFiles    []string
+}
+
+// only stampPkg is searched, a variable named like a version variable in any
+// other package is left alone
+func FindVariables(ctx context.Context, pkg, stampPkg string, tags, env []string) ([]Variable, error) {
+	args := []string{"list", "-deps", "-json=ImportPath,Name,Dir,GoFiles"}
+	if len(tags) > 0 {
+		args = append(args, "-tags="+strings.Join(tags, ","))
+	}
+	output, err := util.ExecResult(ctx, "go", append(args, pkg), env)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not parse go list output: %w", err)
+		}
+		if p.ImportPath == stampPkg {
+			return findPackageVariables(&p)
+		}
+	}
+	return nil, fmt.Errorf("stamp package `%s` is not linked into `%s`", stampPkg, pkg)
+}
+
+func findPackageVariables(p *listedPackage) ([]Variable, error) {
+	importPath := p.ImportPath
+	if p.Name == "main" {
+		importPath = "main"
+	}
+	var vars []Variable
+	fset := token.NewFileSet()
+	for _, name := range p.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(p.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if !isStringSpec(vs) {
+					continue
+				}
+				for _, ident := range vs.Names {
+					if kind, ok := variableKinds[strings.ToLower(ident.Name)]; ok {
+						vars = append(vars, Variable{Name: importPath + "." + ident.Name, Kind: kind})
+					}
+				}
+			}
+		}
+	}
+	return vars, nil
+}
+
+// -X only sets uninitialized strings or strings initialized with constants
+func isStringSpec(vs *ast.ValueSpec) bool {
+	if vs.Type != nil {
+		ident, ok := vs.Type.(*ast.Ident)
+		return ok && ident.Name == "string"
+	}
+	if len(vs.Values) != len(vs.Names) {
+		return false
+	}
+	for _, value := range vs.Values {
+		lit, ok := value.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return false
+		}
+	}
+	return true
+}
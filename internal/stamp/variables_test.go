@@ -0,0 +1,102 @@
+package stamp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindVariables(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"main.go": `package main
+
+import (
+	_ "example.com/app/internal/proto"
+	_ "example.com/app/internal/version"
+)
+
+var version = "dev"
+
+func main() {}
+`,
+		"internal/version/version.go": `package version
+
+var (
+	Version   string
+	GitCommit = "unknown"
+	BuildTime = 0
+	Commit, Other = "a", "b"
+)
+`,
+		"internal/version/version_linux.go": "package version\n\nvar GoVersion string\n",
+		"internal/version/tagged.go":        "//go:build withdate\n\npackage version\n\nvar BuildDate string\n",
+		"internal/proto/proto.go":           "package proto\n\nvar version = \"2\"\n",
+		"internal/unused/unused.go":         "package unused\n\nvar Version string\n",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		stampPkg string
+		tags     []string
+		env      []string
+		want     []string
+		wantErr  string
+	}{
+		{stampPkg: "example.com/app", want: []string{"main.version"}},
+		{
+			stampPkg: "example.com/app/internal/version",
+			env:      []string{"GOOS=linux"},
+			want: []string{
+				"example.com/app/internal/version.Version",
+				"example.com/app/internal/version.GitCommit",
+				"example.com/app/internal/version.Commit",
+				"example.com/app/internal/version.GoVersion",
+			},
+		},
+		{
+			stampPkg: "example.com/app/internal/version",
+			tags:     []string{"withdate"},
+			env:      []string{"GOOS=windows"},
+			want: []string{
+				"example.com/app/internal/version.BuildDate",
+				"example.com/app/internal/version.Version",
+				"example.com/app/internal/version.GitCommit",
+				"example.com/app/internal/version.Commit",
+			},
+		},
+		{stampPkg: "example.com/app/internal/unused", wantErr: "is not linked into"},
+	}
+	for _, tt := range tests {
+		vars, err := FindVariables(context.Background(), "example.com/app", tt.stampPkg, tt.tags, tt.env)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FindVariables(%s) = %v, want %q", tt.stampPkg, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FindVariables(%s): %v", tt.stampPkg, err)
+		}
+		var names []string
+		for _, v := range vars {
+			names = append(names, v.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("FindVariables(%s, %v, %v) = %v, want %v", tt.stampPkg, tt.tags, tt.env, names, tt.want)
+		}
+	}
+}
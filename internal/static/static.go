@@ -0,0 +1,142 @@
+package static
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+// darwin and windows binaries are always linked to the system libraries
+var ErrNotELF = errors.New("not an ELF binary")
+
+type Result struct {
+	// PT_INTERP, the dynamic linker
+	Interpreter string
+	// DT_NEEDED, the shared libraries to load
+	Needed []string
+}
+
+func (r *Result) Static() bool {
+	return r.Interpreter == "" && len(r.Needed) == 0
+}
+
+func (r *Result) String() string {
+	var parts []string
+	if r.Interpreter != "" {
+		parts = append(parts, fmt.Sprintf("interpreter %s", r.Interpreter))
+	}
+	if len(r.Needed) > 0 {
+		parts = append(parts, fmt.Sprintf("needs %s", strings.Join(r.Needed, ", ")))
+	}
+	if len(parts) == 0 {
+		return "static"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func Check(name string) (*Result, error) {
+	f, err := elf.Open(name)
+	if err != nil {
+		var formatErr *elf.FormatError
+		if errors.As(err, &formatErr) {
+			return nil, ErrNotELF
+		}
+		return nil, fmt.Errorf("could not open binary: %w", err)
+	}
+	defer f.Close()
+
+	var result Result
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return nil, fmt.Errorf("could not read PT_INTERP: %w", err)
+		}
+		result.Interpreter = strings.TrimRight(string(data), "\x00")
+	}
+	needed, err := f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil, fmt.Errorf("could not read DT_NEEDED: %w", err)
+	}
+	result.Needed = needed
+	return &result, nil
+}
+
+type listedPackage struct {
+	ImportPath string
+	CgoFiles   []string
+	Imports    []string
+}
+
+// the import chains from pkg, e.g. [main github.com/mattn/go-sqlite3]
+func CgoPackages(ctx context.Context, pkg string, flags, env []string) ([][]string, error) {
+	args := slices.Concat([]string{"list", "-deps", "-json=ImportPath,CgoFiles,Imports"}, flags, []string{pkg})
+	// env is the slice of the unit, shared with the concurrent builds
+	output, err := util.ExecResult(ctx, "go", args, append(slices.Clone(env), "CGO_ENABLED=1"))
+	if err != nil {
+		return nil, err
+	}
+	packages := make(map[string]*listedPackage)
+	var order []string
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("could not parse go list output: %w", err)
+		}
+		packages[p.ImportPath] = &p
+		order = append(order, p.ImportPath)
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	// go list -deps lists the requested package last, walk the imports breadth
+	// first to find the shortest chains
+	root := order[len(order)-1]
+	parent := map[string]string{root: ""}
+	queue := []string{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		p, ok := packages[current]
+		if !ok {
+			continue
+		}
+		for _, imported := range p.Imports {
+			if _, seen := parent[imported]; !seen {
+				parent[imported] = current
+				queue = append(queue, imported)
+			}
+		}
+	}
+
+	var chains [][]string
+	for _, path := range order {
+		p := packages[path]
+		if len(p.CgoFiles) == 0 {
+			continue
+		}
+		if _, ok := parent[path]; !ok {
+			continue
+		}
+		var chain []string
+		for current := path; current != ""; current = parent[current] {
+			chain = append([]string{current}, chain...)
+		}
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
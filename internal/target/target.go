@@ -0,0 +1,114 @@
+package target
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/josexy/catgo/internal/util"
+)
+
+type Platform struct {
+	GOOS         string `json:"GOOS"`
+	GOARCH       string `json:"GOARCH"`
+	CgoSupported bool   `json:"CgoSupported"`
+	FirstClass   bool   `json:"FirstClass"`
+}
+
+func (p Platform) String() string { return p.GOOS + "/" + p.GOARCH }
+
+var (
+	platformsOnce sync.Once
+	platforms     []Platform
+	platformsErr  error
+)
+
+func Platforms() ([]Platform, error) {
+	platformsOnce.Do(func() {
+		output, err := util.ExecResult(context.Background(), "go", []string{"tool", "dist", "list", "-json"}, nil)
+		if err != nil {
+			platformsErr = err
+			return
+		}
+		if err = json.Unmarshal(output, &platforms); err != nil {
+			platformsErr = fmt.Errorf("could not parse go tool dist list output: %w", err)
+		}
+	})
+	return platforms, platformsErr
+}
+
+func Validate(platforms []Platform, goos, goarch string) error {
+	var osList []string
+	archByOS := make(map[string][]string)
+	for _, p := range platforms {
+		if p.GOOS == goos && p.GOARCH == goarch {
+			return nil
+		}
+		if _, ok := archByOS[p.GOOS]; !ok {
+			osList = append(osList, p.GOOS)
+		}
+		archByOS[p.GOOS] = append(archByOS[p.GOOS], p.GOARCH)
+	}
+
+	target := goos + "/" + goarch
+	if goarch == "" {
+		target = goos
+	}
+	suggestOS := goos
+	if _, ok := archByOS[goos]; !ok {
+		suggestOS = closest(goos, osList)
+	}
+	if suggestOS == "" {
+		return fmt.Errorf("unknown target `%s`, run `catgo targets` to list the supported targets", target)
+	}
+	suggestArch := closest(goarch, archByOS[suggestOS])
+	if suggestArch == "" {
+		return fmt.Errorf("unknown target `%s`, run `catgo targets --os %s` to list the supported architectures", target, suggestOS)
+	}
+	return fmt.Errorf("unknown target `%s`, did you mean `%s/%s`?", target, suggestOS, suggestArch)
+}
+
+func closest(s string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := levenshtein(s, candidate)
+		if bestDistance < 0 || d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	if bestDistance < 0 || bestDistance*2 > len(s) {
+		return ""
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func Names(platforms []Platform) []string {
+	names := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		names = append(names, p.String())
+	}
+	sort.Strings(names)
+	return names
+}
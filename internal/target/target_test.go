@@ -0,0 +1,69 @@
+package target
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	platforms := []Platform{
+		{GOOS: "linux", GOARCH: "amd64"},
+		{GOOS: "linux", GOARCH: "arm64"},
+		{GOOS: "darwin", GOARCH: "arm64"},
+	}
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", ""},
+		{"linux", "amd46", "did you mean `linux/amd64`"},
+		{"lnux", "arm64", "did you mean `linux/arm64`"},
+		{"darwn", "arm46", "did you mean `darwin/arm64`"},
+		{"foo", "bar", "run `catgo targets`"},
+	}
+	for _, tt := range tests {
+		err := Validate(platforms, tt.goos, tt.goarch)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Validate(%s/%s) = %v, want nil", tt.goos, tt.goarch, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%s/%s) = %v, want %q", tt.goos, tt.goarch, err, tt.want)
+		}
+	}
+}
+
+func TestParseTriple(t *testing.T) {
+	tests := []struct {
+		target string
+		env    []string
+		suffix string
+	}{
+		{"linux/amd64", []string{"GOOS=linux", "GOARCH=amd64"}, "linux-amd64"},
+		{"linux/arm/v7", []string{"GOOS=linux", "GOARCH=arm", "GOARM=7"}, "linux-armv7"},
+		{"linux/arm/5", []string{"GOOS=linux", "GOARCH=arm", "GOARM=5"}, "linux-armv5"},
+		{"linux/amd64/v3", []string{"GOOS=linux", "GOARCH=amd64", "GOAMD64=v3"}, "linux-amd64v3"},
+		{"linux/arm64/v8.2", []string{"GOOS=linux", "GOARCH=arm64", "GOARM64=v8.2"}, "linux-arm64v8.2"},
+		{"linux/386/softfloat", []string{"GOOS=linux", "GOARCH=386", "GO386=softfloat"}, "linux-386-softfloat"},
+	}
+	for _, tt := range tests {
+		triple, err := ParseTriple(tt.target)
+		if err != nil {
+			t.Fatalf("ParseTriple(%s): %v", tt.target, err)
+		}
+		if env := strings.Join(triple.Env(), " "); env != strings.Join(tt.env, " ") {
+			t.Errorf("ParseTriple(%s).Env() = %s, want %s", tt.target, env, strings.Join(tt.env, " "))
+		}
+		if suffix := triple.Suffix(); suffix != tt.suffix {
+			t.Errorf("ParseTriple(%s).Suffix() = %s, want %s", tt.target, suffix, tt.suffix)
+		}
+	}
+
+	for _, target := range []string{"linux/amd64/v9", "linux/riscv/v1", "linux/arm/v7/x"} {
+		if _, err := ParseTriple(target); err == nil {
+			t.Errorf("ParseTriple(%s) should fail", target)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package target
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// the variant selects the sub-architecture via GOARM, GOAMD64, GOARM64 and friends
+type Triple struct {
+	OS      string
+	Arch    string
+	Variant string
+}
+
+type variantSpec struct {
+	env     string
+	pattern *regexp.Regexp
+	// value converts the variant to the environment value
+	value func(string) string
+}
+
+var variantSpecs = map[string]variantSpec{
+	"arm": {
+		env:     "GOARM",
+		pattern: regexp.MustCompile(`^v?[567](,(softfloat|hardfloat))?$`),
+		value:   func(v string) string { return strings.TrimPrefix(v, "v") },
+	},
+	"amd64": {
+		env:     "GOAMD64",
+		pattern: regexp.MustCompile(`^v[1-4]$`),
+	},
+	"arm64": {
+		env:     "GOARM64",
+		pattern: regexp.MustCompile(`^v(8\.[0-9]|9\.[0-5])(,(lse|crypto))*$`),
+	},
+	"386": {
+		env:     "GO386",
+		pattern: regexp.MustCompile(`^(sse2|softfloat)$`),
+	},
+	"mips": {
+		env:     "GOMIPS",
+		pattern: regexp.MustCompile(`^(hardfloat|softfloat)$`),
+	},
+	"mipsle": {
+		env:     "GOMIPS",
+		pattern: regexp.MustCompile(`^(hardfloat|softfloat)$`),
+	},
+	"mips64": {
+		env:     "GOMIPS64",
+		pattern: regexp.MustCompile(`^(hardfloat|softfloat)$`),
+	},
+	"mips64le": {
+		env:     "GOMIPS64",
+		pattern: regexp.MustCompile(`^(hardfloat|softfloat)$`),
+	},
+	"ppc64": {
+		env:     "GOPPC64",
+		pattern: regexp.MustCompile(`^power(8|9|10)$`),
+	},
+	"ppc64le": {
+		env:     "GOPPC64",
+		pattern: regexp.MustCompile(`^power(8|9|10)$`),
+	},
+	"riscv64": {
+		env:     "GORISCV64",
+		pattern: regexp.MustCompile(`^rva2[023]u64$`),
+	},
+}
+
+// the os and arch are not validated against the supported platforms
+func ParseTriple(s string) (Triple, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 || parts[0] == "" {
+		return Triple{}, fmt.Errorf("invalid target `%s`, expected os/arch[/variant]", s)
+	}
+	var t Triple
+	t.OS = parts[0]
+	if len(parts) > 1 {
+		t.Arch = parts[1]
+	}
+	if len(parts) > 2 {
+		t.Variant = parts[2]
+		spec, ok := variantSpecs[t.Arch]
+		if !ok {
+			return Triple{}, fmt.Errorf("invalid target `%s`, architecture `%s` has no variants", s, t.Arch)
+		}
+		if !spec.pattern.MatchString(t.Variant) {
+			return Triple{}, fmt.Errorf("invalid target `%s`, unknown %s variant `%s`", s, t.Arch, t.Variant)
+		}
+		if t.Arch == "arm" && !strings.HasPrefix(t.Variant, "v") {
+			t.Variant = "v" + t.Variant
+		}
+	}
+	return t, nil
+}
+
+func (t Triple) String() string {
+	s := t.OS
+	if t.Arch != "" {
+		s += "/" + t.Arch
+	}
+	if t.Variant != "" {
+		s += "/" + t.Variant
+	}
+	return s
+}
+
+func (t Triple) Env() []string {
+	var env []string
+	if t.OS != "" {
+		env = append(env, "GOOS="+t.OS)
+	}
+	if t.Arch != "" {
+		env = append(env, "GOARCH="+t.Arch)
+	}
+	if t.Variant != "" {
+		spec := variantSpecs[t.Arch]
+		value := t.Variant
+		if spec.value != nil {
+			value = spec.value(value)
+		}
+		env = append(env, spec.env+"="+value)
+	}
+	return env
+}
+
+func (t Triple) Suffix() string {
+	s := t.OS
+	if t.Arch != "" {
+		s += "-" + t.Arch
+	}
+	if t.Variant != "" {
+		variant := strings.ReplaceAll(t.Variant, ",", "-")
+		if strings.HasPrefix(variant, "v") {
+			s += variant
+		} else {
+			s += "-" + variant
+		}
+	}
+	return s
+}
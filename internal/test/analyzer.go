@@ -10,7 +10,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"text/tabwriter"
 	"time"
 
 	"github.com/josexy/catgo/internal/util"
@@ -108,7 +107,6 @@ type UnitTestEvent struct {
 
 type LinesOutputAnalyzer struct {
 	br *bufio.Reader
-	wr io.Writer
 
 	verbose       bool
 	moduleName    string
@@ -131,7 +129,6 @@ func New(reader io.Reader, moduleName string, mode TestMode, cpus []string, verb
 		verbose:    verbose,
 		mode:       mode,
 		cpus:       cpus,
-		wr:         util.Output,
 		br:         bufio.NewReader(reader),
 		events:     make(map[string]*PackageTestEvent, 128),
 	}
@@ -173,34 +170,17 @@ func (a *LinesOutputAnalyzer) runner() {
 }
 
 func (a *LinesOutputAnalyzer) printTestSummary() {
-	tw := tabwriter.NewWriter(a.wr, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(tw)
-	fmt.Fprintln(a.wr, "Test summary:")
-	fmt.Fprintln(tw, "PACKAGE\tSTATUS\tPASSED\tFAILED\tSKIPPED\tELAPSED")
-	defer tw.Flush()
-	var totalPassed, totalFailed, totalSkipped int
-	var totalElapsed time.Duration
+	var summary util.TestSummary
 	for packageName, pv := range a.events {
-		totalPassed += pv.PassedTests
-		totalFailed += pv.FailedTests
-		totalSkipped += pv.SkippedTests
-		totalElapsed += pv.Elapsed
-		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n",
-			formatPackage(packageName, a.moduleName),
-			pv.Status.String(),
-			pv.PassedTests,
-			pv.FailedTests,
-			pv.SkippedTests,
-			pv.Elapsed.String(),
-		)
+		summary.Passed += pv.PassedTests
+		summary.Failed += pv.FailedTests
+		summary.Skipped += pv.SkippedTests
+		summary.Elapsed += pv.Elapsed
+		result := pv.result()
+		result.Package = formatPackage(packageName, a.moduleName)
+		summary.Packages = append(summary.Packages, result)
 	}
-	fmt.Fprintln(tw)
-	fmt.Fprintf(a.wr, "test result: %d passed, %d failed, %d skipped, finished in %s\n\n",
-		totalPassed,
-		totalFailed,
-		totalSkipped,
-		totalElapsed.String(),
-	)
+	util.Printer.PrintTestSummary(summary)
 }
 
 func (a *LinesOutputAnalyzer) analyzeEvent(event *TestEvent) (err error) {
@@ -240,7 +220,7 @@ func (a *LinesOutputAnalyzer) analyzeEvent(event *TestEvent) (err error) {
 				}
 			}
 			if a.verbose && cont {
-				fmt.Fprint(a.wr, event.Output)
+				util.Printer.PrintTestOutput(event.Package, event.Test, event.Output)
 			}
 		}
 	case "pass":
@@ -358,38 +338,42 @@ func (a *LinesOutputAnalyzer) printPackageEvent(pv *PackageTestEvent) {
 }
 
 func (a *LinesOutputAnalyzer) printPackageEventResult(pv *PackageTestEvent) {
-	util.Printer.BoldGreen.Print("   Finished")
-	fmt.Fprintf(a.wr, " test package(%s) result: %s, %d passed, %d failed, %d skipped, finished in %s\n",
-		pv.PackageName,
-		prettyStatus(pv.Status),
-		pv.PassedTests,
-		pv.FailedTests,
-		pv.SkippedTests,
-		pv.Elapsed.String(),
-	)
+	util.Printer.PrintPackageTestResult(pv.result())
 }
 
 func (a *LinesOutputAnalyzer) printUnitTestEventResult(name string, uv *UnitTestEvent) {
-	switch uv.Status {
-	case StatusPass:
-		util.Printer.BoldGreen.Print("     Passed")
-	case StatusFail:
-		util.Printer.Red.Print("     Failed")
-	case StatusSkip:
-		util.Printer.Cyan.Print("     Skipped")
-	}
-	fmt.Fprintf(a.wr, " %s.%s in %s\n", formatPackage(name, a.moduleName), uv.TestName, uv.Elapsed.String())
+	util.Printer.PrintTestResult(util.TestResult{
+		Package: formatPackage(name, a.moduleName),
+		Test:    uv.TestName,
+		Status:  uv.Status.String(),
+		Elapsed: uv.Elapsed,
+	})
 }
 
 func (a *LinesOutputAnalyzer) printBenchmarkEventResult(name string, be *BenchmarkEvent) {
-	util.Printer.BoldGreen.Print("     Done")
-	fmt.Fprintf(a.wr, " %s.%s in %d iterations, %s/op, %d B/op, %d allocs/op\n",
-		formatPackage(name, a.moduleName), be.TestName, be.Iterations, be.NsPerOp.String(), be.BytesPerOp, be.AllocsPerOp)
+	util.Printer.PrintBenchmarkResult(util.BenchmarkResult{
+		Package:     formatPackage(name, a.moduleName),
+		Test:        be.TestName,
+		Iterations:  be.Iterations,
+		NsPerOp:     be.NsPerOp,
+		BytesPerOp:  be.BytesPerOp,
+		AllocsPerOp: be.AllocsPerOp,
+	})
 }
 
 func (a *LinesOutputAnalyzer) printRunningTestEvent(name, testName string) {
-	util.Printer.BoldGreen.Print("     Running")
-	fmt.Fprintf(a.wr, " %s.%s\n", formatPackage(name, a.moduleName), testName)
+	util.Printer.PrintTestRunning(formatPackage(name, a.moduleName), testName)
+}
+
+func (pv *PackageTestEvent) result() util.PackageTestResult {
+	return util.PackageTestResult{
+		Package: pv.PackageName,
+		Status:  pv.Status.String(),
+		Passed:  pv.PassedTests,
+		Failed:  pv.FailedTests,
+		Skipped: pv.SkippedTests,
+		Elapsed: pv.Elapsed,
+	}
 }
 
 func formatPackage(s1, s2 string) string {
@@ -400,19 +384,6 @@ func formatPackage(s1, s2 string) string {
 	return s2
 }
 
-func prettyStatus(status TestStatus) string {
-	switch status {
-	case StatusPass:
-		return util.Printer.BoldGreen.Sprint(status.String())
-	case StatusFail, StatusBuildFail:
-		return util.Printer.Red.Sprint(status.String())
-	case StatusSkip:
-		return util.Printer.Cyan.Sprint(status.String())
-	default:
-		return util.Printer.Yellow.Sprint(status.String())
-	}
-}
-
 func filterNoneOutputTestLog(s string) bool {
 	return strings.HasPrefix(s, runTestPrefix) ||
 		strings.HasPrefix(s, passTestPrefix) ||
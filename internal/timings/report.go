@@ -0,0 +1,93 @@
+package timings
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+type Report struct {
+	Module    string
+	Profile   string
+	StartTime time.Time
+	Duration  time.Duration
+	Targets   []*Target
+}
+
+const slowestLimit = 30
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(d time.Duration) string { return fmt.Sprintf("%.2fs", d.Seconds()) },
+	"percent": func(d, total time.Duration) string {
+		if total <= 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%.3f", float64(d)*100/float64(total))
+	},
+	"slowest": func(t *Target) []Unit { return t.Slowest(slowestLimit) },
+	"inc":     func(i int) int { return i + 1 },
+	"compiled": func(t *Target) int {
+		compiled, _ := t.Compiled()
+		return compiled
+	},
+	"cached": func(t *Target) int {
+		_, cached := t.Compiled()
+		return cached
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>catgo build timings: {{.Module}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; font-size: 0.9em; }
+th { background: #f0f0f0; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.timeline { position: relative; border-left: 1px solid #999; margin: 1em 0; }
+.row { position: relative; height: 18px; margin: 2px 0; }
+.bar { position: absolute; height: 16px; background: #4e9a06; color: #fff; font-size: 11px; line-height: 16px; white-space: nowrap; overflow: visible; padding-left: 2px; box-sizing: border-box; min-width: 2px; }
+.bar.link { background: #3465a4; }
+.bar span { color: #222; position: absolute; left: 100%; padding-left: 4px; }
+</style>
+</head>
+<body>
+<h1>catgo build timings</h1>
+<table>
+<tr><th>Module</th><td>{{.Module}}</td></tr>
+<tr><th>Profile</th><td>{{.Profile}}</td></tr>
+<tr><th>Started</th><td>{{.StartTime.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Total time</th><td>{{seconds .Duration}}</td></tr>
+</table>
+{{range $target := .Targets}}
+<h2>{{$target.Name}}</h2>
+<table>
+<tr><th>Output</th><td>{{$target.Output}}</td></tr>
+<tr><th>Build time</th><td>{{seconds $target.Duration}}</td></tr>
+<tr><th>Compiled units</th><td>{{compiled $target}}</td></tr>
+<tr><th>Cached units</th><td>{{cached $target}}</td></tr>
+</table>
+<h3>Timeline</h3>
+<div class="timeline">
+{{range $target.Units}}{{if not .Cached}}<div class="row"><div class="bar {{.Mode}}" style="left: {{percent .Start $target.Duration}}%; width: {{percent .Duration $target.Duration}}%" title="{{.Package}} ({{.Mode}}) {{seconds .Duration}}"><span>{{.Package}} {{seconds .Duration}}</span></div></div>
+{{end}}{{end}}</div>
+<h3>Slowest packages</h3>
+<table>
+<tr><th>#</th><th>Package</th><th>Step</th><th>Duration</th><th>Process time</th></tr>
+{{range $i, $unit := slowest $target}}<tr><td class="num">{{inc $i}}</td><td>{{$unit.Package}}</td><td>{{$unit.Mode}}</td><td class="num">{{seconds $unit.Duration}}</td><td class="num">{{seconds $unit.CmdReal}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+func WriteHTML(w io.Writer, report *Report) error {
+	if err := reportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("could not render timing report: %w", err)
+	}
+	return nil
+}
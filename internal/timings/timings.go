@@ -0,0 +1,102 @@
+package timings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+type action struct {
+	ID        int
+	Mode      string
+	Package   string
+	TimeStart time.Time
+	TimeDone  time.Time
+	Cmd       []string
+	CmdReal   time.Duration
+}
+
+type Unit struct {
+	Package  string
+	Mode     string
+	Cached   bool
+	Start    time.Duration
+	Duration time.Duration
+	// wall time of the compiler or linker process
+	CmdReal time.Duration
+}
+
+type Target struct {
+	Name     string
+	Output   string
+	Duration time.Duration
+	Units    []Unit
+}
+
+func LoadActionGraph(name string) ([]Unit, time.Duration, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not read action graph: %w", err)
+	}
+	var actions []action
+	if err = json.Unmarshal(data, &actions); err != nil {
+		return nil, 0, fmt.Errorf("could not parse action graph: %w", err)
+	}
+
+	var begin, end time.Time
+	for _, a := range actions {
+		if a.TimeStart.IsZero() {
+			continue
+		}
+		if begin.IsZero() || a.TimeStart.Before(begin) {
+			begin = a.TimeStart
+		}
+		if a.TimeDone.After(end) {
+			end = a.TimeDone
+		}
+	}
+
+	var units []Unit
+	for _, a := range actions {
+		if (a.Mode != "build" && a.Mode != "link") || a.TimeStart.IsZero() {
+			continue
+		}
+		units = append(units, Unit{
+			Package:  a.Package,
+			Mode:     a.Mode,
+			Cached:   len(a.Cmd) == 0,
+			Start:    a.TimeStart.Sub(begin),
+			Duration: a.TimeDone.Sub(a.TimeStart),
+			CmdReal:  a.CmdReal,
+		})
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Start < units[j].Start })
+	return units, end.Sub(begin), nil
+}
+
+func (t *Target) Slowest(n int) []Unit {
+	var units []Unit
+	for _, unit := range t.Units {
+		if !unit.Cached {
+			units = append(units, unit)
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].Duration > units[j].Duration })
+	if len(units) > n {
+		units = units[:n]
+	}
+	return units
+}
+
+func (t *Target) Compiled() (compiled, cached int) {
+	for _, unit := range t.Units {
+		if unit.Cached {
+			cached++
+		} else {
+			compiled++
+		}
+	}
+	return
+}
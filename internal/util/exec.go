@@ -12,10 +12,14 @@ import (
 	"time"
 )
 
+var Stdout io.Writer = os.Stdout
+
 type ExecIO struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
+	// runs the command with the given environment only
+	ClearEnv bool
 }
 
 func FormatDuration(d time.Duration) string {
@@ -27,6 +31,19 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dm %.2fs", minutes, seconds)
 }
 
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func FormatCommandArgs(command string, args []string) string {
 	if len(args) == 0 {
 		return command
@@ -45,12 +62,15 @@ func ExecImmediate(ctx context.Context, command string, args []string, env []str
 		cmd.Stdin = os.Stdin
 	}
 	if cmd.Stdout == nil {
-		cmd.Stdout = os.Stdout
+		cmd.Stdout = Stdout
 	}
 	if cmd.Stderr == nil {
 		cmd.Stderr = os.Stderr
 	}
 	cmd.Env = cmd.Environ()
+	if len(io) > 0 && io[0].ClearEnv {
+		cmd.Env = []string{}
+	}
 	if len(env) > 0 {
 		cmd.Env = append(cmd.Env, env...)
 	}
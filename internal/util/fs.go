@@ -3,8 +3,11 @@ package util
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -41,6 +44,19 @@ func WriteFile(name string, data []byte) error {
 	return nil
 }
 
+func FileSHA256(name string) (string, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	defer fp.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, fp); err != nil {
+		return "", fmt.Errorf("could not read file %s: %w", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func CurrentGoModFile() (string, error) {
 	output, err := ExecResult(context.Background(), "go", []string{"env", "GOMOD"}, nil)
 	if err != nil {
@@ -0,0 +1,196 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type JSONPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewJSONPrinter(w io.Writer) *JSONPrinter {
+	return &JSONPrinter{w: w}
+}
+
+func (p *JSONPrinter) emit(reason string, v any) {
+	fields := map[string]any{}
+	if v != nil {
+		data, _ := json.Marshal(v)
+		json.Unmarshal(data, &fields)
+	}
+	fields["reason"] = reason
+	data, _ := json.Marshal(fields)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.w.Write(append(data, '\n'))
+}
+
+type statusMessage struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func (p *JSONPrinter) status(status, message string) {
+	p.emit("status", statusMessage{Status: status, Message: message})
+}
+
+func (p *JSONPrinter) PrintTesting(target string)   { p.status("testing", target) }
+func (p *JSONPrinter) PrintCompiling(target string) { p.status("compiling", target) }
+func (p *JSONPrinter) PrintFresh(target string)     { p.status("fresh", target) }
+func (p *JSONPrinter) PrintRunning(target string)   { p.status("running", target) }
+func (p *JSONPrinter) PrintCreated(item string)     { p.status("created", item) }
+func (p *JSONPrinter) PrintAdding(pkg string)       { p.status("adding", pkg) }
+func (p *JSONPrinter) PrintRemoving(pkg string)     { p.status("removing", pkg) }
+func (p *JSONPrinter) PrintUpdating(item string)    { p.status("updating", item) }
+func (p *JSONPrinter) PrintVendoring(item string)   { p.status("vendoring", item) }
+func (p *JSONPrinter) PrintStamping(item string)    { p.status("stamping", item) }
+func (p *JSONPrinter) PrintPackaging(item string)   { p.status("packaging", item) }
+func (p *JSONPrinter) PrintGenerating(item string)  { p.status("generating", item) }
+func (p *JSONPrinter) PrintVerifying(item string)   { p.status("verifying", item) }
+func (p *JSONPrinter) PrintSuccess(msg string)      { p.status("success", msg) }
+
+func (p *JSONPrinter) PrintFinished(profile string, duration string) {
+	p.emit("build-finished", struct {
+		Success  bool   `json:"success"`
+		Profile  string `json:"profile"`
+		Duration string `json:"duration"`
+	}{true, profile, duration})
+}
+
+func (p *JSONPrinter) PrintBuildFailed(profile string, duration string, err error) {
+	p.emit("build-finished", struct {
+		Success  bool   `json:"success"`
+		Profile  string `json:"profile,omitempty"`
+		Duration string `json:"duration"`
+		Error    string `json:"error"`
+	}{false, profile, duration, err.Error()})
+}
+
+func (p *JSONPrinter) PrintRemoved(items []string) {
+	p.emit("removed", struct {
+		Files []string `json:"files"`
+	}{items})
+}
+
+func (p *JSONPrinter) PrintError(msg string) {
+	p.emit("error", struct {
+		Message string `json:"message"`
+	}{msg})
+}
+
+func (p *JSONPrinter) PrintWarning(msg string) {
+	p.emit("warning", struct {
+		Message string `json:"message"`
+	}{msg})
+}
+
+func (p *JSONPrinter) PrintArtifact(artifact Artifact) {
+	p.emit("compiler-artifact", artifact)
+}
+
+func (p *JSONPrinter) PrintBuildSummary(results []BuildTargetResult) {
+	p.emit("build-summary", struct {
+		Targets []BuildTargetResult `json:"targets"`
+	}{results})
+}
+
+func (p *JSONPrinter) PrintEnvironment(target string, env []string) {
+	p.emit("build-environment", struct {
+		Target string   `json:"target"`
+		Env    []string `json:"env"`
+	}{target, env})
+}
+
+func (p *JSONPrinter) PrintTestRunning(pkg, test string) {
+	p.emit("test-started", struct {
+		Package string `json:"package"`
+		Test    string `json:"test"`
+	}{pkg, test})
+}
+
+func (p *JSONPrinter) PrintTestOutput(pkg, test, output string) {
+	p.emit("test-output", struct {
+		Package string `json:"package"`
+		Test    string `json:"test,omitempty"`
+		Output  string `json:"output"`
+	}{pkg, test, output})
+}
+
+func (p *JSONPrinter) PrintTestResult(result TestResult) {
+	p.emit("test-result", result)
+}
+
+func (p *JSONPrinter) PrintPackageTestResult(result PackageTestResult) {
+	p.emit("test-package-result", result)
+}
+
+func (p *JSONPrinter) PrintBenchmarkResult(result BenchmarkResult) {
+	p.emit("benchmark-result", result)
+}
+
+func (p *JSONPrinter) PrintTestSummary(summary TestSummary) {
+	p.emit("test-summary", summary)
+}
+
+func (p *JSONPrinter) BuildOutput(target string) io.WriteCloser {
+	return &diagnosticWriter{printer: p, target: target}
+}
+
+// e.g. main.go:8:12: undefined: x
+var diagnosticPattern = regexp.MustCompile(`^(\S+?\.\w+):(\d+)(?::(\d+))?: (.*)$`)
+
+type diagnosticWriter struct {
+	printer *JSONPrinter
+	target  string
+	pkg     string
+	buf     bytes.Buffer
+}
+
+func (w *diagnosticWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// keep the incomplete line for the next write
+			w.buf.WriteString(line)
+			break
+		}
+		w.emit(strings.TrimRight(line, "\r\n"))
+	}
+	return len(data), nil
+}
+
+func (w *diagnosticWriter) Close() error {
+	if w.buf.Len() > 0 {
+		w.emit(w.buf.String())
+		w.buf.Reset()
+	}
+	return nil
+}
+
+func (w *diagnosticWriter) emit(line string) {
+	if line == "" {
+		return
+	}
+	if pkg, ok := strings.CutPrefix(line, "# "); ok {
+		w.pkg = pkg
+		return
+	}
+	d := Diagnostic{Target: w.target, Package: w.pkg, Message: line}
+	// go build indents the continuation lines with a tab
+	if m := diagnosticPattern.FindStringSubmatch(strings.TrimLeft(line, " \t")); m != nil {
+		d.File = m[1]
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		d.Message = m[4]
+	}
+	w.printer.emit("compiler-message", d)
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewJSONPrinter(&out).BuildOutput("host")
+	w.Write([]byte("# example.com/demo\n./main.go:8:12: undefined: x\n\t./bad.go:3:2: declared and not used: y\n\thave (int)\n"))
+	w.Close()
+
+	var got []Diagnostic
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var d Diagnostic
+		if err := json.Unmarshal([]byte(line), &d); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, d)
+	}
+	want := []Diagnostic{
+		{Target: "host", Package: "example.com/demo", File: "./main.go", Line: 8, Column: 12, Message: "undefined: x"},
+		{Target: "host", Package: "example.com/demo", File: "./bad.go", Line: 3, Column: 2, Message: "declared and not used: y"},
+		{Target: "host", Package: "example.com/demo", Message: "\thave (int)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d: %s", len(got), len(want), out.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package util
+
+import "time"
+
+type Artifact struct {
+	Path    string `json:"path"`
+	Target  string `json:"target"`
+	Profile string `json:"profile"`
+	Size    int64  `json:"size"`
+}
+
+// File, Line and Column are empty for the output lines which are not positioned
+type Diagnostic struct {
+	Target  string `json:"target"`
+	Package string `json:"package,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type BuildTargetResult struct {
+	Target  string        `json:"target"`
+	Status  string        `json:"status"`
+	Output  string        `json:"output"`
+	Size    int64         `json:"size"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+type TestResult struct {
+	Package string        `json:"package"`
+	Test    string        `json:"test"`
+	Status  string        `json:"status"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+type PackageTestResult struct {
+	Package string        `json:"package"`
+	Status  string        `json:"status"`
+	Passed  int           `json:"passed"`
+	Failed  int           `json:"failed"`
+	Skipped int           `json:"skipped"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+type BenchmarkResult struct {
+	Package     string        `json:"package"`
+	Test        string        `json:"test"`
+	Iterations  int64         `json:"iterations"`
+	NsPerOp     time.Duration `json:"ns_per_op"`
+	BytesPerOp  int64         `json:"bytes_per_op"`
+	AllocsPerOp int64         `json:"allocs_per_op"`
+}
+
+type TestSummary struct {
+	Packages []PackageTestResult `json:"packages"`
+	Passed   int                 `json:"passed"`
+	Failed   int                 `json:"failed"`
+	Skipped  int                 `json:"skipped"`
+	Elapsed  time.Duration       `json:"elapsed_ns"`
+}
@@ -2,12 +2,73 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
 
 	"github.com/fatih/color"
 )
 
 var Output = color.Output
 
+type Renderer interface {
+	PrintTesting(target string)
+	PrintCompiling(target string)
+	PrintFresh(target string)
+	PrintFinished(profile string, duration string)
+	PrintBuildFailed(profile string, duration string, err error)
+	PrintRunning(target string)
+	PrintCreated(item string)
+	PrintAdding(pkg string)
+	PrintRemoving(pkg string)
+	PrintRemoved(items []string)
+	PrintUpdating(item string)
+	PrintVendoring(item string)
+	PrintStamping(item string)
+	PrintPackaging(item string)
+	PrintGenerating(item string)
+	PrintVerifying(item string)
+	PrintSuccess(msg string)
+	PrintError(msg string)
+	PrintWarning(msg string)
+
+	// must be closed after the build finished
+	BuildOutput(target string) io.WriteCloser
+	PrintArtifact(artifact Artifact)
+	PrintBuildSummary(results []BuildTargetResult)
+	PrintEnvironment(target string, env []string)
+
+	PrintTestRunning(pkg, test string)
+	PrintTestOutput(pkg, test, output string)
+	PrintTestResult(result TestResult)
+	PrintPackageTestResult(result PackageTestResult)
+	PrintBenchmarkResult(result BenchmarkResult)
+	PrintTestSummary(summary TestSummary)
+}
+
+const (
+	MessageFormatHuman = "human"
+	MessageFormatJSON  = "json"
+)
+
+var Printer Renderer = Colors
+
+// in the JSON format the output of the executed commands and the tables of the
+// commands are written to stderr to keep stdout parsable
+func SetMessageFormat(format string) error {
+	switch format {
+	case MessageFormatHuman, "":
+		Printer = Colors
+	case MessageFormatJSON:
+		Printer = NewJSONPrinter(os.Stdout)
+		Stdout = os.Stderr
+		Output = color.Error
+	default:
+		return fmt.Errorf("unknown message format `%s`, expected %s or %s", format, MessageFormatHuman, MessageFormatJSON)
+	}
+	return nil
+}
+
 type ColorPrinter struct {
 	Green     *color.Color
 	Red       *color.Color
@@ -17,7 +78,7 @@ type ColorPrinter struct {
 	BoldGreen *color.Color
 }
 
-var Printer = &ColorPrinter{
+var Colors = &ColorPrinter{
 	Green:     color.New(color.FgGreen),
 	Red:       color.New(color.FgRed),
 	Yellow:    color.New(color.FgYellow),
@@ -36,11 +97,19 @@ func (p *ColorPrinter) PrintCompiling(target string) {
 	fmt.Printf(" %s\n", target)
 }
 
+func (p *ColorPrinter) PrintFresh(target string) {
+	p.BoldGreen.Print("       Fresh")
+	fmt.Printf(" %s\n", target)
+}
+
 func (p *ColorPrinter) PrintFinished(profile string, duration string) {
 	p.BoldGreen.Print("    Finished")
 	fmt.Printf(" `%s` target(s) in %s\n", profile, duration)
 }
 
+// the error is printed by the root command
+func (p *ColorPrinter) PrintBuildFailed(profile string, duration string, err error) {}
+
 func (p *ColorPrinter) PrintRunning(target string) {
 	p.BoldGreen.Print("     Running")
 	fmt.Printf(" `%s`\n", target)
@@ -79,6 +148,26 @@ func (p *ColorPrinter) PrintVendoring(item string) {
 	fmt.Printf(" %s\n", item)
 }
 
+func (p *ColorPrinter) PrintStamping(item string) {
+	p.BoldGreen.Print("    Stamping")
+	fmt.Printf(" %s\n", item)
+}
+
+func (p *ColorPrinter) PrintPackaging(item string) {
+	p.BoldGreen.Print("   Packaging")
+	fmt.Printf(" %s\n", item)
+}
+
+func (p *ColorPrinter) PrintGenerating(item string) {
+	p.BoldGreen.Print("  Generating")
+	fmt.Printf(" %s\n", item)
+}
+
+func (p *ColorPrinter) PrintVerifying(item string) {
+	p.BoldGreen.Print("   Verifying")
+	fmt.Printf(" %s\n", item)
+}
+
 func (p *ColorPrinter) PrintSuccess(msg string) {
 	p.Green.Print("success")
 	fmt.Printf(": %s\n", msg)
@@ -93,3 +182,117 @@ func (p *ColorPrinter) PrintWarning(msg string) {
 	p.Yellow.Print("warning")
 	fmt.Printf(": %s\n", msg)
 }
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func (p *ColorPrinter) BuildOutput(target string) io.WriteCloser {
+	return nopCloser{os.Stderr}
+}
+
+func (p *ColorPrinter) PrintArtifact(artifact Artifact) {}
+
+func (p *ColorPrinter) PrintEnvironment(target string, env []string) {
+	p.BoldGreen.Print(" Environment")
+	fmt.Fprintf(Output, " %s\n", target)
+	for _, kv := range env {
+		fmt.Fprintf(Output, "             %s\n", kv)
+	}
+}
+
+func (p *ColorPrinter) PrintBuildSummary(results []BuildTargetResult) {
+	tw := tabwriter.NewWriter(Output, 0, 0, 3, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Build summary:")
+	fmt.Fprintln(tw, "TARGET\tSTATUS\tSIZE\tELAPSED\tOUTPUT")
+	for _, result := range results {
+		size, elapsed := "-", "-"
+		if result.Size > 0 {
+			size = FormatSize(result.Size)
+		}
+		if result.Elapsed > 0 {
+			elapsed = FormatDuration(result.Elapsed)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.Target, result.Status, size, elapsed, result.Output)
+	}
+	fmt.Fprintln(tw)
+}
+
+func (p *ColorPrinter) PrintTestRunning(pkg, test string) {
+	p.BoldGreen.Print("     Running")
+	fmt.Fprintf(Output, " %s.%s\n", pkg, test)
+}
+
+func (p *ColorPrinter) PrintTestOutput(pkg, test, output string) {
+	fmt.Fprint(Output, output)
+}
+
+func (p *ColorPrinter) PrintTestResult(result TestResult) {
+	switch result.Status {
+	case "PASS":
+		p.BoldGreen.Print("     Passed")
+	case "FAILED":
+		p.Red.Print("     Failed")
+	case "SKIPPED":
+		p.Cyan.Print("     Skipped")
+	}
+	fmt.Fprintf(Output, " %s.%s in %s\n", result.Package, result.Test, result.Elapsed.String())
+}
+
+func (p *ColorPrinter) PrintPackageTestResult(result PackageTestResult) {
+	p.BoldGreen.Print("   Finished")
+	fmt.Fprintf(Output, " test package(%s) result: %s, %d passed, %d failed, %d skipped, finished in %s\n",
+		result.Package,
+		p.prettyStatus(result.Status),
+		result.Passed,
+		result.Failed,
+		result.Skipped,
+		result.Elapsed.String(),
+	)
+}
+
+func (p *ColorPrinter) PrintBenchmarkResult(result BenchmarkResult) {
+	p.BoldGreen.Print("     Done")
+	fmt.Fprintf(Output, " %s.%s in %d iterations, %s/op, %d B/op, %d allocs/op\n",
+		result.Package, result.Test, result.Iterations, result.NsPerOp.String(), result.BytesPerOp, result.AllocsPerOp)
+}
+
+func (p *ColorPrinter) PrintTestSummary(summary TestSummary) {
+	fmt.Fprintln(Output)
+	fmt.Fprintln(Output, "Test summary:")
+	tw := tabwriter.NewWriter(Output, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tSTATUS\tPASSED\tFAILED\tSKIPPED\tELAPSED")
+	for _, result := range summary.Packages {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			result.Package,
+			result.Status,
+			result.Passed,
+			result.Failed,
+			result.Skipped,
+			result.Elapsed.String(),
+		)
+	}
+	fmt.Fprintln(tw)
+	tw.Flush()
+	fmt.Fprintf(Output, "test result: %d passed, %d failed, %d skipped, finished in %s\n\n",
+		summary.Passed,
+		summary.Failed,
+		summary.Skipped,
+		summary.Elapsed.String(),
+	)
+}
+
+func (p *ColorPrinter) prettyStatus(status string) string {
+	switch status {
+	case "PASS":
+		return p.BoldGreen.Sprint(status)
+	case "FAILED":
+		return p.Red.Sprint(status)
+	case "SKIPPED":
+		return p.Cyan.Sprint(status)
+	default:
+		return p.Yellow.Sprint(status)
+	}
+}
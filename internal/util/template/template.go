@@ -42,5 +42,38 @@ go.work.sum
 # .idea/
 # .vscode/
 
+target/
 bin/
+dist/
+.catgo/
+`
+
+const ManifestFile = `# catgo manifest, flags on the command line override these settings.
+
+[package]
+# Default package to build, relative to the module root.
+main = "."
+# Output binary name, default to the last element of the module path.
+output = "%s"
+# Build script package run before the build, see "Build Scripts" in the README.
+# build = "./build"
+
+[build]
+# Build in release mode.
+# release = false
+# Set CGO_ENABLED explicitly.
+# cgo = false
+# Build with the vendor directory.
+# vendor = false
+# Output layout: target for target/<profile>/<os>-<arch>/, bin for the flat bin/ directory.
+# layout = "target"
+
+# Linker -X variables.
+[build.set]
+# "main.Version" = "0.1.0"
+
+[test]
+package = "./..."
+# race = false
+# timeout = "30s"
 `